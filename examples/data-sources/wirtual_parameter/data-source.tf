@@ -47,10 +47,11 @@ data "wirtual_parameter" "cores" {
 }
 
 data "wirtual_parameter" "disk_size" {
-  name    = "Disk Size"
-  type    = "number"
-  default = "5"
-  order   = 8
+  name      = "Disk Size"
+  type      = "number"
+  default   = "5"
+  order     = 8
+  form_type = "slider"
   validation {
     # This can apply to number.
     min       = 0
@@ -77,6 +78,10 @@ data "wirtual_parameter" "fairy_tale" {
   mutable   = true
   default   = "Hansel and Gretel"
   ephemeral = true
+  form_type = "textarea"
+  styling {
+    placeholder = "Once upon a time..."
+  }
 }
 
 data "wirtual_parameter" "users" {
@@ -114,4 +119,80 @@ data "wirtual_parameter" "home_volume_size" {
   validation {
     monotonic = "increasing"
   }
-}
\ No newline at end of file
+}
+data "wirtual_parameter" "use_gpu" {
+  name    = "use_gpu"
+  type    = "bool"
+  default = false
+  mutable = true
+}
+
+data "wirtual_parameter" "gpu_type" {
+  name    = "gpu_type"
+  type    = "string"
+  default = "nvidia-tesla-t4"
+  mutable = true
+  option {
+    name  = "NVIDIA T4"
+    value = "nvidia-tesla-t4"
+  }
+  option {
+    name  = "NVIDIA A100"
+    value = "nvidia-tesla-a100"
+  }
+  visible_when {
+    parameter = data.wirtual_parameter.use_gpu.name
+    value     = data.wirtual_parameter.use_gpu.value
+    values    = ["true"]
+  }
+}
+
+data "wirtual_parameter" "hostname" {
+  name    = "hostname"
+  type    = "string"
+  default = "dev-box"
+  validation {
+    min_length = 3
+    max_length = 63
+    error      = "The hostname must be {min} to {max} characters long."
+  }
+  validation {
+    regex = "^[a-z0-9-]+$"
+    error = "The hostname may only contain lowercase letters, digits and dashes."
+  }
+}
+
+data "wirtual_parameter" "api_key" {
+  name      = "api_key"
+  type      = "string"
+  mutable   = true
+  sensitive = true
+  default   = ""
+}
+
+data "wirtual_parameter" "labels" {
+  name    = "labels"
+  type    = "map(string)"
+  default = jsonencode({ team = "platform" })
+  mutable = true
+}
+
+data "wirtual_parameter" "repositories" {
+  name = "repositories"
+  type = "object"
+  schema = jsonencode({
+    type = "array"
+    items = {
+      type = "object"
+      properties = {
+        url    = { type = "string" }
+        branch = { type = "string" }
+      }
+      required = ["url"]
+    }
+  })
+  default = jsonencode([
+    { url = "https://github.com/wirtualdev/wirtual", branch = "main" },
+  ])
+  mutable = true
+}
@@ -0,0 +1,13 @@
+# Users must still authenticate with the external auth provider before
+# creating a workspace.
+data "wirtual_external_auth" "github" {
+  id = "github"
+}
+
+ephemeral "wirtual_external_auth_token" "github" {
+  id = data.wirtual_external_auth.github.id
+}
+
+provider "github" {
+  token = ephemeral.wirtual_external_auth_token.github.access_token
+}
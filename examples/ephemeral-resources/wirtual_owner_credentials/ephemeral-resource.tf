@@ -0,0 +1,8 @@
+ephemeral "wirtual_owner_credentials" "me" {}
+
+provider "wirtuald" {
+  url   = data.wirtual_workspace.me.access_url
+  token = ephemeral.wirtual_owner_credentials.me.session_token
+}
+
+data "wirtual_workspace" "me" {}
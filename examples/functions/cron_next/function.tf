@@ -0,0 +1,10 @@
+resource "wirtual_script" "backup" {
+  agent_id     = wirtual_agent.dev.id
+  display_name = "Backup"
+  script       = "backup.sh"
+  cron         = "0 0 22 * * *"
+}
+
+output "next_backups" {
+  value = provider::wirtual::cron_next(wirtual_script.backup.cron, 3, plantimestamp())
+}
@@ -0,0 +1,3 @@
+output "github_token_env" {
+  value = provider::wirtual::external_auth_env_name("github")
+}
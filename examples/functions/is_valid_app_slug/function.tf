@@ -0,0 +1,7 @@
+variable "app_slug" {
+  type = string
+  validation {
+    condition     = provider::wirtual::is_valid_app_slug(var.app_slug)
+    error_message = "The app slug must be a valid hostname without consecutive hyphens."
+  }
+}
@@ -0,0 +1,5 @@
+# Pass a parameter value to a template unit test:
+#   export WIRTUAL_PARAMETER_c697d2981bf416569a16cfbcdec1542b5398f3cc77d2b905819aa99c46ecf6f6=us-east1-a
+output "region_env" {
+  value = provider::wirtual::parameter_env_name("region")
+}
@@ -0,0 +1,10 @@
+variable "disk_size" {
+  type = number
+  validation {
+    condition = can(provider::wirtual::validate_parameter("number", tostring(var.disk_size), {
+      min = 10
+      max = 100
+    }))
+    error_message = "The disk size must be between 10 and 100 GB."
+  }
+}
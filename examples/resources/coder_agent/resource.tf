@@ -29,6 +29,23 @@ resource "wirtual_agent" "dev" {
     order        = 1
   }
 
+  resources_monitoring {
+    memory {
+      threshold = 90
+    }
+    volume {
+      path      = "/home/wirtual"
+      threshold = 80
+    }
+  }
+
+  token_rotation {
+    interval_days = 30
+    keepers = {
+      template_version = data.wirtual_workspace.me.template_version
+    }
+  }
+
   order = 1
 }
 
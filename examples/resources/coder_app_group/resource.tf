@@ -0,0 +1,29 @@
+resource "wirtual_agent" "dev" {
+  os   = "linux"
+  arch = "amd64"
+  dir  = "/workspace"
+}
+
+resource "wirtual_app_group" "databases" {
+  agent_id     = wirtual_agent.dev.id
+  name         = "databases"
+  display_name = "Databases"
+  icon         = "/icon/database.svg"
+  order        = 1
+}
+
+resource "wirtual_app" "psql" {
+  agent_id     = wirtual_agent.dev.id
+  slug         = "psql"
+  display_name = "PostgreSQL"
+  command      = "psql"
+  group        = wirtual_app_group.databases.name
+}
+
+resource "wirtual_app" "redis-cli" {
+  agent_id     = wirtual_agent.dev.id
+  slug         = "redis-cli"
+  display_name = "Redis"
+  command      = "redis-cli"
+  group        = wirtual_app_group.databases.name
+}
@@ -0,0 +1,21 @@
+data "wirtual_workspace" "me" {}
+
+resource "wirtual_agent" "dev" {
+  os   = "linux"
+  arch = "amd64"
+  dir  = "/workspace"
+}
+
+resource "wirtual_script" "clone" {
+  agent_id     = wirtual_agent.dev.id
+  display_name = "Clone repository"
+  run_on_start = true
+  script       = "git clone https://github.com/wirtualdev/wirtual /workspace/wirtual"
+}
+
+resource "wirtual_devcontainer" "wirtual" {
+  count            = data.wirtual_workspace.me.start_count
+  agent_id         = wirtual_agent.dev.id
+  workspace_folder = "/workspace/wirtual"
+  config_path      = ".devcontainer/devcontainer.json"
+}
@@ -21,6 +21,7 @@ resource "wirtual_app" "hidden" {
   agent_id = wirtual_agent.dev.id
   slug     = "hidden"
   share    = "owner"
+  url      = "http://localhost:8080"
   hidden   = true
 }
 
@@ -28,6 +29,7 @@ resource "wirtual_app" "visible" {
   agent_id = wirtual_agent.dev.id
   slug     = "visible"
   share    = "owner"
+  url      = "http://localhost:8080"
   hidden   = false
 }
 
@@ -35,6 +37,7 @@ resource "wirtual_app" "defaulted" {
   agent_id = wirtual_agent.dev.id
   slug     = "defaulted"
   share    = "owner"
+  url      = "http://localhost:8080"
 }
 
 locals {
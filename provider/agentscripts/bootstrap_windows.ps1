@@ -0,0 +1,63 @@
+$ProgressPreference = "SilentlyContinue"
+$ErrorActionPreference = "Stop"
+# Sleep for a good long while before exiting. This allows folks to connect to
+# a failed workspace and poke around to troubleshoot.
+trap {
+	Write-Error "=== Agent script exited with an error: $_. Sleeping 24h to preserve logs..."
+	Start-Sleep -Seconds 86400
+}
+
+$BinaryDir = Join-Path $env:TEMP "wirtual"
+New-Item -ItemType Directory -Force -Path $BinaryDir | Out-Null
+$BinaryFile = Join-Path $BinaryDir "wirtual.exe"
+$BinaryURL = "${BINARY_URL}"
+$BinarySHA256 = "${BINARY_SHA256}"
+$CABundleBase64 = "${CA_BUNDLE_BASE64}"
+[Net.ServicePointManager]::SecurityProtocol = [Net.SecurityProtocolType]::Tls12
+
+# Proxy settings apply to the download and are inherited by the agent.
+$WebRequestArgs = @{ Uri = $BinaryURL; OutFile = $BinaryFile; UseBasicParsing = $true }
+if ("${HTTP_PROXY_URL}" -ne "") {
+	$env:HTTP_PROXY = "${HTTP_PROXY_URL}"
+	$WebRequestArgs.Proxy = "${HTTP_PROXY_URL}"
+}
+if ("${HTTPS_PROXY_URL}" -ne "") {
+	$env:HTTPS_PROXY = "${HTTPS_PROXY_URL}"
+	if ($BinaryURL.StartsWith("https:")) {
+		$WebRequestArgs.Proxy = "${HTTPS_PROXY_URL}"
+	}
+}
+if ("${NO_PROXY_HOSTS}" -ne "") {
+	$env:NO_PROXY = "${NO_PROXY_HOSTS}"
+}
+if ($CABundleBase64 -ne "") {
+	$CAFile = Join-Path $BinaryDir "ca.pem"
+	[IO.File]::WriteAllBytes($CAFile, [Convert]::FromBase64String($CABundleBase64))
+	Import-Certificate -FilePath $CAFile -CertStoreLocation Cert:\LocalMachine\Root | Out-Null
+}
+
+# Downloading the agent can fail for a number of reasons, many of which are
+# likely transient, so keep trying.
+while ($true) {
+	try {
+		Invoke-WebRequest @WebRequestArgs
+		break
+	} catch {
+		Write-Output "error: failed to download the wirtual agent from $($BinaryURL): $_"
+		Write-Output "Trying again in 30 seconds..."
+		Start-Sleep -Seconds 30
+	}
+}
+
+if ($BinarySHA256 -ne "") {
+	$Actual = (Get-FileHash -Path $BinaryFile -Algorithm SHA256).Hash.ToLower()
+	if ($Actual -ne $BinarySHA256) {
+		throw "the wirtual agent has SHA-256 $Actual, expected $BinarySHA256"
+	}
+}
+
+Write-Output "Starting agent ${AGENT_ID} (${OS}/${ARCH})"
+$env:WIRTUAL_AGENT_AUTH = "${AUTH_TYPE}"
+$env:WIRTUAL_AGENT_URL = "${ACCESS_URL}"
+Set-Location $BinaryDir
+& $BinaryFile agent
@@ -2,13 +2,20 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"net/url"
+	"reflect"
 	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"golang.org/x/xerrors"
 )
 
 var (
@@ -21,6 +28,10 @@ var (
 	//
 	// There are test cases for this regex in the Wirtual product.
 	appSlugRegex = regexp.MustCompile(`^[a-z0-9](-?[a-z0-9])*$`)
+
+	// healthcheckStatusCodeRegex matches a status code, or an inclusive range
+	// of status codes, accepted by an app healthcheck.
+	healthcheckStatusCodeRegex = regexp.MustCompile(`^(\d{3})(?:-(\d{3}))?$`)
 )
 
 func appResource() *schema.Resource {
@@ -30,36 +41,10 @@ func appResource() *schema.Resource {
 		Description: "Use this resource to define shortcuts to access applications in a workspace.",
 		CreateContext: func(c context.Context, resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
 			resourceData.SetId(uuid.NewString())
-
-			diags := diag.Diagnostics{}
-
-			hiddenData := resourceData.Get("hidden")
-			if hidden, ok := hiddenData.(bool); !ok {
-				return diag.Errorf("hidden should be a bool")
-			} else if hidden {
-				if _, ok := resourceData.GetOk("display_name"); ok {
-					diags = append(diags, diag.Diagnostic{
-						Severity: diag.Warning,
-						Summary:  "`display_name` set when app is hidden",
-					})
-				}
-
-				if _, ok := resourceData.GetOk("icon"); ok {
-					diags = append(diags, diag.Diagnostic{
-						Severity: diag.Warning,
-						Summary:  "`icon` set when app is hidden",
-					})
-				}
-
-				if _, ok := resourceData.GetOk("order"); ok {
-					diags = append(diags, diag.Diagnostic{
-						Severity: diag.Warning,
-						Summary:  "`order` set when app is hidden",
-					})
-				}
-			}
-
-			return diags
+			return setAppAccessURL(resourceData, i)
+		},
+		UpdateContext: func(c context.Context, resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return setAppAccessURL(resourceData, i)
 		},
 		ReadContext: func(c context.Context, resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
 			return nil
@@ -78,7 +63,7 @@ func appResource() *schema.Resource {
 				Type: schema.TypeString,
 				Description: "A command to run in a terminal opening this app. In the web, " +
 					"this will open in a new tab. In the CLI, this will SSH and execute the command. " +
-					"Either `command` or `url` may be specified, but not both.",
+					"Exactly one of `command` or `url` must be specified.",
 				ConflictsWith: []string{"url"},
 				Optional:      true,
 				ForceNew:      true,
@@ -88,7 +73,6 @@ func appResource() *schema.Resource {
 				Description: "A URL to an icon that will display in the dashboard. View built-in " +
 					"icons here: https://github.com/wirtualdev/wirtual/tree/main/site/static/icon. Use a " +
 					"built-in icon with `\"${data.wirtual_workspace.me.access_url}/icon/<path>\"`.",
-				ForceNew: true,
 				Optional: true,
 				ValidateFunc: func(i interface{}, s string) ([]string, []error) {
 					_, err := url.Parse(s)
@@ -122,14 +106,12 @@ func appResource() *schema.Resource {
 			"display_name": {
 				Type:        schema.TypeString,
 				Description: "A display name to identify the app. Defaults to the slug.",
-				ForceNew:    true,
 				Optional:    true,
 			},
 			"name": {
 				Type:          schema.TypeString,
 				Description:   "A display name to identify the app.",
 				Deprecated:    "`name` on apps is deprecated, use `display_name` instead",
-				ForceNew:      true,
 				Optional:      true,
 				ConflictsWith: []string{"display_name"},
 			},
@@ -182,8 +164,8 @@ func appResource() *schema.Resource {
 			"url": {
 				Type: schema.TypeString,
 				Description: "An external url if `external=true` or a URL to be proxied to from inside the workspace. " +
-					"This should be of the form `http://localhost:PORT[/SUBPATH]`. " +
-					"Either `command` or `url` may be specified, but not both.",
+					"Proxied URLs must be of the form `http(s)://HOST:PORT[/SUBPATH]`, e.g. `http://localhost:8080`. " +
+					"Exactly one of `command` or `url` must be specified.",
 				ForceNew:      true,
 				Optional:      true,
 				ConflictsWith: []string{"command"},
@@ -198,8 +180,9 @@ func appResource() *schema.Resource {
 				ConflictsWith: []string{"healthcheck", "command", "subdomain", "share"},
 			},
 			"healthcheck": {
-				Type:          schema.TypeSet,
-				Description:   "HTTP health checking to determine the application readiness.",
+				Type: schema.TypeSet,
+				Description: "Health checking to determine the application readiness. Exactly one probe kind " +
+					"must be set: an HTTP probe with `url`, a TCP probe with `tcp` or a command probe with `exec`.",
 				ForceNew:      true,
 				Optional:      true,
 				MaxItems:      1,
@@ -207,10 +190,54 @@ func appResource() *schema.Resource {
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"url": {
+							Type: schema.TypeString,
+							Description: "HTTP address used determine the application readiness. A successful health check is " +
+								"a HTTP response code less than 500, or one of `status_codes` if set, returned before " +
+								"`healthcheck.timeout` seconds. Must use the same scheme as the app `url`.",
+							ForceNew: true,
+							Optional: true,
+						},
+						"status_codes": {
+							Type: schema.TypeList,
+							Description: "HTTP response codes considered healthy, either single codes like `\"200\"` " +
+								"or inclusive ranges like `\"200-299\"`. Only valid with `url`.",
+							ForceNew: true,
+							Optional: true,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validateHealthcheckStatusCode,
+							},
+						},
+						"headers": {
+							Type:        schema.TypeMap,
+							Description: "HTTP headers to send with the health check request. Only valid with `url`.",
+							ForceNew:    true,
+							Optional:    true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"body_contains": {
 							Type:        schema.TypeString,
-							Description: "HTTP address used determine the application readiness. A successful health check is a HTTP response code less than 500 returned before `healthcheck.interval` seconds.",
+							Description: "A substring the response body must contain for the health check to succeed. Only valid with `url`.",
 							ForceNew:    true,
-							Required:    true,
+							Optional:    true,
+						},
+						"tcp": {
+							Type:         schema.TypeString,
+							Description:  "A `host:port` address that must accept TCP connections for the application to be ready.",
+							ForceNew:     true,
+							Optional:     true,
+							ValidateFunc: validateHealthcheckTCPAddress,
+						},
+						"exec": {
+							Type:        schema.TypeList,
+							Description: "A command, and its arguments, run inside the workspace. The application is ready when the command exits with code zero.",
+							ForceNew:    true,
+							Optional:    true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
 						},
 						"interval": {
 							Type:        schema.TypeInt,
@@ -218,6 +245,21 @@ func appResource() *schema.Resource {
 							ForceNew:    true,
 							Required:    true,
 						},
+						"timeout": {
+							Type:         schema.TypeInt,
+							Description:  "Duration in seconds after which a single health check is considered failed. Must not exceed `interval`. Defaults to `interval`.",
+							ForceNew:     true,
+							Optional:     true,
+							ValidateFunc: validation.IntAtLeast(1),
+						},
+						"initial_delay": {
+							Type:         schema.TypeInt,
+							Description:  "Duration in seconds to wait after the agent starts before the first health check.",
+							ForceNew:     true,
+							Optional:     true,
+							Default:      0,
+							ValidateFunc: validation.IntAtLeast(0),
+						},
 						"threshold": {
 							Type:        schema.TypeInt,
 							Description: "Number of consecutive heathcheck failures before returning an unhealthy status.",
@@ -230,16 +272,329 @@ func appResource() *schema.Resource {
 			"order": {
 				Type:        schema.TypeInt,
 				Description: "The order determines the position of app in the UI presentation. The lowest order is shown first and apps with equal order are sorted by name (ascending order).",
-				ForceNew:    true,
 				Optional:    true,
 			},
 			"hidden": {
 				Type:        schema.TypeBool,
-				Description: "Determines if the app is visible in the UI (minimum Wirtual version: v2.16).",
+				Description: "Determines if the app is visible in the UI (minimum Wirtual version: v2.16). External apps cannot be hidden.",
 				Default:     false,
-				ForceNew:    true,
 				Optional:    true,
 			},
+			"agent_name": {
+				Type: schema.TypeString,
+				Description: "The name of the `wirtual_agent` resource the app belongs to, e.g. `\"dev\"` for " +
+					"`wirtual_agent.dev`. Only used to build `access_url`, and may be omitted when the " +
+					"workspace has a single agent.",
+				Optional: true,
+			},
+			"access_url": {
+				Type: schema.TypeString,
+				Description: "The URL the app is reachable at, derived from the provider `url`, the workspace, " +
+					"its owner and the app settings. For `external` apps this is the `url` itself. Subdomain " +
+					"apps are served from the wildcard access URL passed to the provider in " +
+					"`WIRTUAL_WILDCARD_ACCESS_URL`, and the attribute is empty when it is not set. Always " +
+					"empty for `command` apps.",
+				Computed: true,
+			},
+			"group": {
+				Type: schema.TypeString,
+				Description: "The name of a menu to collapse the app into in the dashboard. Apps with the same " +
+					"`group` are shown together. Use the `name` of a `wirtual_app_group` resource to " +
+					"customize how the menu is displayed.",
+				Optional:     true,
+				ValidateFunc: validation.StringIsNotWhiteSpace,
+			},
+			"open_in": {
+				Type: schema.TypeString,
+				Description: "Determines where the app is opened in the dashboard. Valid values are " +
+					"`\"slim-window\"` (default), `\"tab\"` and `\"embedded\"`. `\"slim-window\"` " +
+					"opens the app in a popup window without browser controls, `\"tab\"` opens it in a " +
+					"new browser tab and `\"embedded\"` shows it inside the workspace page. Embedded " +
+					"apps must set a `url` and cannot be `external`.",
+				Optional: true,
+				Default:  "slim-window",
+				ValidateDiagFunc: func(val interface{}, c cty.Path) diag.Diagnostics {
+					valStr, ok := val.(string)
+					if !ok {
+						return diag.Errorf("expected string, got %T", val)
+					}
+
+					switch valStr {
+					case "slim-window", "tab", "embedded":
+						return nil
+					}
+
+					return diag.Errorf("invalid app open_in %q, must be one of \"slim-window\", \"tab\", \"embedded\"", valStr)
+				},
+			},
+		},
+		CustomizeDiff: func(ctx context.Context, rd *schema.ResourceDiff, i interface{}) error {
+			err := validateAppURL(rd)
+			if err != nil {
+				return err
+			}
+			err = planErrors(ctx, hiddenAppWarnings(rd))
+			if err != nil {
+				return err
+			}
+			err = validateAppHealthcheck(rd)
+			if err != nil {
+				return err
+			}
+			err = validateAppOpenIn(rd)
+			if err != nil {
+				return err
+			}
+			return planAppAccessURL(rd, i)
 		},
 	}
 }
+
+// appAccessURLKeys are the attributes of a wirtual_app that its access URL
+// is derived from.
+var appAccessURLKeys = []string{"slug", "agent_name", "url", "command", "subdomain", "external"}
+
+// planAppAccessURL plans the "access_url" of a wirtual_app, so it is updated
+// whenever the app, workspace or owner change.
+func planAppAccessURL(rd *schema.ResourceDiff, i interface{}) error {
+	config, valid := i.(config)
+	if !valid {
+		return xerrors.Errorf("config was unexpected type %q", reflect.TypeOf(i).String())
+	}
+	for _, key := range appAccessURLKeys {
+		if !rd.NewValueKnown(key) {
+			return rd.SetNewComputed("access_url")
+		}
+	}
+	return rd.SetNew("access_url", appAccessURL(config, rd))
+}
+
+// setAppAccessURL sets the "access_url" of a wirtual_app.
+func setAppAccessURL(resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
+	config, valid := i.(config)
+	if !valid {
+		return diag.Errorf("config was unexpected type %q", reflect.TypeOf(i).String())
+	}
+	err := resourceData.Set("access_url", appAccessURL(config, resourceData))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// appAccessURL returns the URL an app is reachable at through Wirtual. Path
+// apps are served from "/@<owner>/<workspace>.<agent>/apps/<slug>/" on the
+// access URL, and subdomain apps from "<slug>--<agent>--<workspace>--<owner>"
+// on the wildcard access URL. The agent is omitted when its name is unknown.
+func appAccessURL(config config, app interface{ Get(string) interface{} }) string {
+	command, _ := app.Get("command").(string)
+	rawURL, _ := app.Get("url").(string)
+	if command != "" || rawURL == "" {
+		return ""
+	}
+	if external, _ := app.Get("external").(bool); external {
+		return rawURL
+	}
+
+	slug, _ := app.Get("slug").(string)
+	agentName, _ := app.Get("agent_name").(string)
+	workspace := config.BuildContext.Workspace.Name
+	owner := config.BuildContext.Owner.Name
+
+	if subdomain, _ := app.Get("subdomain").(bool); subdomain {
+		wildcard := config.BuildContext.WildcardAccessURL
+		if wildcard == "" {
+			return ""
+		}
+		labels := []string{slug, agentName, workspace, owner}
+		if agentName == "" {
+			labels = []string{slug, workspace, owner}
+		}
+		host := strings.Replace(wildcard, "*", strings.Join(labels, "--"), 1)
+		if _, _, err := net.SplitHostPort(host); err != nil && config.URL.Port() != "" {
+			host = net.JoinHostPort(host, config.URL.Port())
+		}
+		return (&url.URL{Scheme: config.URL.Scheme, Host: host, Path: "/"}).String()
+	}
+
+	workspaceAndAgent := workspace
+	if agentName != "" {
+		workspaceAndAgent += "." + agentName
+	}
+	return config.URL.JoinPath("@"+owner, workspaceAndAgent, "apps", slug).String() + "/"
+}
+
+// validateAppURL ensures an app has a "url" or a "command", that proxied URLs
+// are HTTP URLs with a host, and that external apps are visible.
+func validateAppURL(rd *schema.ResourceDiff) error {
+	config := rd.GetRawConfig()
+	rawURL := config.GetAttr("url")
+	if rawURL.IsNull() && config.GetAttr("command").IsNull() {
+		return xerrors.New(`one of "url" or "command" must be set`)
+	}
+
+	external := config.GetAttr("external")
+	isExternal := external.IsKnown() && !external.IsNull() && external.True()
+	if hidden := config.GetAttr("hidden"); isExternal && hidden.IsKnown() && !hidden.IsNull() && hidden.True() {
+		return cty.GetAttrPath("hidden").NewErrorf("external apps cannot be hidden")
+	}
+	if isExternal || !rawURL.IsKnown() || rawURL.IsNull() {
+		return nil
+	}
+
+	appURL, err := url.Parse(rawURL.AsString())
+	if err != nil {
+		return cty.GetAttrPath("url").NewErrorf("invalid app url %q: %s", rawURL.AsString(), err)
+	}
+	if (appURL.Scheme != "http" && appURL.Scheme != "https") || appURL.Hostname() == "" {
+		return cty.GetAttrPath("url").NewErrorf("app url %q must be an http:// or https:// URL with a host, e.g. \"http://localhost:8080\"", rawURL.AsString())
+	}
+
+	healthchecks := config.GetAttr("healthcheck")
+	if !healthchecks.IsKnown() || healthchecks.IsNull() {
+		return nil
+	}
+	for _, healthcheck := range healthchecks.AsValueSlice() {
+		if !healthcheck.IsKnown() || healthcheck.IsNull() {
+			continue
+		}
+		rawHealthcheckURL := healthcheck.GetAttr("url")
+		if !rawHealthcheckURL.IsKnown() || rawHealthcheckURL.IsNull() {
+			continue
+		}
+		healthcheckURL, err := url.Parse(rawHealthcheckURL.AsString())
+		if err != nil {
+			return xerrors.Errorf("invalid healthcheck url %q: %w", rawHealthcheckURL.AsString(), err)
+		}
+		if healthcheckURL.Scheme != appURL.Scheme {
+			return xerrors.Errorf("healthcheck url %q must use the same scheme as the app url %q", rawHealthcheckURL.AsString(), rawURL.AsString())
+		}
+	}
+	return nil
+}
+
+// validateAppOpenIn ensures embedded apps proxy a URL from the workspace.
+func validateAppOpenIn(rd *schema.ResourceDiff) error {
+	if !rd.NewValueKnown("open_in") || rd.Get("open_in").(string) != "embedded" {
+		return nil
+	}
+	if external, ok := rd.Get("external").(bool); ok && external {
+		return xerrors.New(`"open_in" cannot be "embedded" for an external app`)
+	}
+	if rd.NewValueKnown("url") && rd.Get("url").(string) == "" {
+		return xerrors.New(`"open_in" set to "embedded" requires a "url"`)
+	}
+	return nil
+}
+
+// validateAppHealthcheck ensures the "healthcheck" block of a wirtual_app sets
+// exactly one probe kind, and only uses HTTP options with an HTTP probe.
+func validateAppHealthcheck(rd *schema.ResourceDiff) error {
+	healthchecks := rd.GetRawConfig().GetAttr("healthcheck")
+	if !healthchecks.IsKnown() || healthchecks.IsNull() {
+		return nil
+	}
+	for _, healthcheck := range healthchecks.AsValueSlice() {
+		if !healthcheck.IsKnown() || healthcheck.IsNull() {
+			continue
+		}
+		isSet := func(name string) bool {
+			return !healthcheck.GetAttr(name).IsNull()
+		}
+
+		probes := 0
+		for _, name := range []string{"url", "tcp", "exec"} {
+			if isSet(name) {
+				probes++
+			}
+		}
+		if probes != 1 {
+			return xerrors.New(`exactly one of "url", "tcp" or "exec" must be set in an app healthcheck`)
+		}
+
+		if !isSet("url") {
+			for _, name := range []string{"status_codes", "headers", "body_contains"} {
+				if isSet(name) {
+					return xerrors.Errorf(`healthcheck %q can only be set with "url"`, name)
+				}
+			}
+		}
+		if exec := healthcheck.GetAttr("exec"); exec.IsKnown() && !exec.IsNull() && exec.LengthInt() == 0 {
+			return xerrors.New(`healthcheck "exec" must contain a command`)
+		}
+
+		interval, intervalKnown := knownInt(healthcheck.GetAttr("interval"))
+		if intervalKnown && interval < 1 {
+			return xerrors.Errorf("healthcheck interval must be at least 1 second, got %d", interval)
+		}
+		if timeout, ok := knownInt(healthcheck.GetAttr("timeout")); ok && intervalKnown && timeout > interval {
+			return xerrors.Errorf("healthcheck timeout %d must not exceed the interval %d", timeout, interval)
+		}
+	}
+	return nil
+}
+
+// validateHealthcheckStatusCode validates an HTTP status code, or an inclusive
+// range of them, accepted by an app healthcheck.
+func validateHealthcheckStatusCode(i interface{}, _ string) ([]string, []error) {
+	value, ok := i.(string)
+	if !ok {
+		return nil, []error{xerrors.Errorf("expected string, got %T", i)}
+	}
+	match := healthcheckStatusCodeRegex.FindStringSubmatch(value)
+	if match == nil {
+		return nil, []error{xerrors.Errorf(`invalid status code %q, must be a code like "200" or a range like "200-299"`, value)}
+	}
+	start, _ := strconv.Atoi(match[1])
+	end := start
+	if match[2] != "" {
+		end, _ = strconv.Atoi(match[2])
+	}
+	if start < 100 || end > 599 || start > end {
+		return nil, []error{xerrors.Errorf("invalid status code %q, codes must be between 100 and 599 and ranges must be ascending", value)}
+	}
+	return nil, nil
+}
+
+// validateHealthcheckTCPAddress validates the "host:port" address of a TCP
+// app healthcheck.
+func validateHealthcheckTCPAddress(i interface{}, _ string) ([]string, []error) {
+	value, ok := i.(string)
+	if !ok {
+		return nil, []error{xerrors.Errorf("expected string, got %T", i)}
+	}
+	host, port, err := net.SplitHostPort(value)
+	if err != nil {
+		return nil, []error{xerrors.Errorf("invalid tcp address %q: %w", value, err)}
+	}
+	if host == "" {
+		return nil, []error{xerrors.Errorf("invalid tcp address %q: missing host", value)}
+	}
+	if number, err := strconv.Atoi(port); err != nil || number < 1 || number > 65535 {
+		return nil, []error{xerrors.Errorf("invalid tcp address %q: port must be between 1 and 65535", value)}
+	}
+	return nil, nil
+}
+
+// hiddenAppWarnings warns about presentational attributes that have no effect
+// because the app is hidden.
+func hiddenAppWarnings(rd *schema.ResourceDiff) diag.Diagnostics {
+	diags := diag.Diagnostics{}
+
+	hiddenData := rd.Get("hidden")
+	if hidden, ok := hiddenData.(bool); !ok {
+		return diag.Errorf("hidden should be a bool")
+	} else if hidden {
+		for _, key := range []string{"display_name", "icon", "order", "group"} {
+			if _, ok := rd.GetOk(key); ok {
+				diags = append(diags, diag.Diagnostic{
+					Severity:      diag.Warning,
+					Summary:       fmt.Sprintf("`%s` set when app is hidden", key),
+					AttributePath: cty.GetAttrPath(key),
+				})
+			}
+		}
+	}
+
+	return diags
+}
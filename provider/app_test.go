@@ -2,15 +2,17 @@ package provider_test
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/stretchr/testify/require"
-	"github.com/wirtualdev/terraform-provider-wirtual/provider"
 )
 
 func TestApp(t *testing.T) {
@@ -20,10 +22,8 @@ func TestApp(t *testing.T) {
 		t.Parallel()
 
 		resource.Test(t, resource.TestCase{
-			Providers: map[string]*schema.Provider{
-				"wirtual": provider.New(),
-			},
-			IsUnitTest: true,
+			ProtoV6ProviderFactories: wirtualFactory(),
+			IsUnitTest:               true,
 			Steps: []resource.TestStep{{
 				Config: `
 				provider "wirtual" {
@@ -129,10 +129,8 @@ func TestApp(t *testing.T) {
 			t.Run(tc.name, func(t *testing.T) {
 				t.Parallel()
 				resource.Test(t, resource.TestCase{
-					Providers: map[string]*schema.Provider{
-						"wirtual": provider.New(),
-					},
-					IsUnitTest: true,
+					ProtoV6ProviderFactories: wirtualFactory(),
+					IsUnitTest:               true,
 					Steps: []resource.TestStep{{
 						Config: tc.config,
 						Check: func(state *terraform.State) error {
@@ -235,10 +233,383 @@ func TestApp(t *testing.T) {
 				}
 
 				resource.Test(t, resource.TestCase{
-					Providers: map[string]*schema.Provider{
-						"wirtual": provider.New(),
-					},
-					IsUnitTest: true,
+					ProtoV6ProviderFactories: wirtualFactory(),
+					IsUnitTest:               true,
+					Steps: []resource.TestStep{{
+						Config:      config,
+						Check:       checkFn,
+						ExpectError: c.expectError,
+					}},
+				})
+			})
+		}
+	})
+
+	t.Run("OpenIn", func(t *testing.T) {
+		t.Parallel()
+
+		cases := []struct {
+			name        string
+			value       string
+			attributes  string
+			expectValue string
+			expectError *regexp.Regexp
+		}{
+			{
+				name:        "Default",
+				value:       "", // default
+				attributes:  `url = "http://localhost:13337"`,
+				expectValue: "slim-window",
+			},
+			{
+				name:        "InvalidValue",
+				value:       "window",
+				attributes:  `url = "http://localhost:13337"`,
+				expectError: regexp.MustCompile(`invalid app open_in "window"`),
+			},
+			{
+				name:        "ExplicitTab",
+				value:       "tab",
+				attributes:  `command = "htop"`,
+				expectValue: "tab",
+			},
+			{
+				name:        "ExplicitEmbedded",
+				value:       "embedded",
+				attributes:  `url = "http://localhost:13337"`,
+				expectValue: "embedded",
+			},
+			{
+				name:        "EmbeddedExternal",
+				value:       "embedded",
+				attributes:  "url = \"https://example.com\"\nexternal = true",
+				expectError: regexp.MustCompile(`"open_in" cannot be "embedded" for an external app`),
+			},
+			{
+				name:        "EmbeddedCommand",
+				value:       "embedded",
+				attributes:  `command = "htop"`,
+				expectError: regexp.MustCompile(`"open_in" set to "embedded" requires a "url"`),
+			},
+		}
+
+		for _, c := range cases {
+			c := c
+
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				openInLine := ""
+				if c.value != "" {
+					openInLine = fmt.Sprintf("open_in = %q", c.value)
+				}
+				config := fmt.Sprintf(`
+				provider "wirtual" {
+				}
+				resource "wirtual_agent" "dev" {
+					os = "linux"
+					arch = "amd64"
+				}
+				resource "wirtual_app" "code-server" {
+					agent_id = wirtual_agent.dev.id
+					slug = "code-server"
+					display_name = "code-server"
+					%s
+					%s
+				}
+				`, c.attributes, openInLine)
+
+				checkFn := func(state *terraform.State) error {
+					require.Len(t, state.Modules, 1)
+					require.Len(t, state.Modules[0].Resources, 2)
+					resource := state.Modules[0].Resources["wirtual_app.code-server"]
+					require.NotNil(t, resource)
+					require.Equal(t, c.expectValue, resource.Primary.Attributes["open_in"])
+					return nil
+				}
+				if c.expectError != nil {
+					checkFn = nil
+				}
+
+				resource.Test(t, resource.TestCase{
+					ProtoV6ProviderFactories: wirtualFactory(),
+					IsUnitTest:               true,
+					Steps: []resource.TestStep{{
+						Config:      config,
+						Check:       checkFn,
+						ExpectError: c.expectError,
+					}},
+				})
+			})
+		}
+	})
+
+	t.Run("Healthcheck", func(t *testing.T) {
+		t.Parallel()
+
+		cases := []struct {
+			name         string
+			healthcheck  string
+			expectValues map[string]string
+			expectError  *regexp.Regexp
+		}{
+			{
+				name: "HTTP",
+				healthcheck: `
+					url = "http://localhost:13337/healthz"
+					status_codes = ["200-299", "401"]
+					headers = {
+						Authorization = "Bearer token"
+					}
+					body_contains = "ok"
+					interval = 5
+					timeout = 2
+					initial_delay = 10
+					threshold = 6`,
+				expectValues: map[string]string{
+					"healthcheck.0.url":                   "http://localhost:13337/healthz",
+					"healthcheck.0.status_codes.#":        "2",
+					"healthcheck.0.status_codes.0":        "200-299",
+					"healthcheck.0.status_codes.1":        "401",
+					"healthcheck.0.headers.Authorization": "Bearer token",
+					"healthcheck.0.body_contains":         "ok",
+					"healthcheck.0.timeout":               "2",
+					"healthcheck.0.initial_delay":         "10",
+				},
+			},
+			{
+				name: "TCP",
+				healthcheck: `
+					tcp = "localhost:5432"
+					interval = 5
+					threshold = 6`,
+				expectValues: map[string]string{
+					"healthcheck.0.tcp":           "localhost:5432",
+					"healthcheck.0.initial_delay": "0",
+				},
+			},
+			{
+				name: "Exec",
+				healthcheck: `
+					exec = ["pg_isready", "-h", "localhost"]
+					interval = 5
+					threshold = 6`,
+				expectValues: map[string]string{
+					"healthcheck.0.exec.#": "3",
+					"healthcheck.0.exec.0": "pg_isready",
+				},
+			},
+			{
+				name: "NoProbe",
+				healthcheck: `
+					interval = 5
+					threshold = 6`,
+				expectError: regexp.MustCompile(`exactly one of "url", "tcp" or "exec" must be set`),
+			},
+			{
+				name: "MultipleProbes",
+				healthcheck: `
+					url = "http://localhost:13337/healthz"
+					tcp = "localhost:13337"
+					interval = 5
+					threshold = 6`,
+				expectError: regexp.MustCompile(`exactly one of "url", "tcp" or "exec" must be set`),
+			},
+			{
+				name: "EmptyExec",
+				healthcheck: `
+					exec = []
+					interval = 5
+					threshold = 6`,
+				expectError: regexp.MustCompile(`healthcheck "exec" must contain a command`),
+			},
+			{
+				name: "StatusCodesWithoutURL",
+				healthcheck: `
+					tcp = "localhost:13337"
+					status_codes = ["200"]
+					interval = 5
+					threshold = 6`,
+				expectError: regexp.MustCompile(`healthcheck "status_codes" can only be set with "url"`),
+			},
+			{
+				name: "InvalidStatusCode",
+				healthcheck: `
+					url = "http://localhost:13337/healthz"
+					status_codes = ["299-200"]
+					interval = 5
+					threshold = 6`,
+				expectError: regexp.MustCompile(`invalid status code "299-200"`),
+			},
+			{
+				name: "InvalidTCPAddress",
+				healthcheck: `
+					tcp = "localhost"
+					interval = 5
+					threshold = 6`,
+				expectError: regexp.MustCompile(`invalid tcp address "localhost"`),
+			},
+			{
+				name: "TimeoutExceedsInterval",
+				healthcheck: `
+					url = "http://localhost:13337/healthz"
+					interval = 5
+					timeout = 10
+					threshold = 6`,
+				expectError: regexp.MustCompile(`healthcheck timeout 10 must not exceed the interval 5`),
+			},
+		}
+
+		for _, c := range cases {
+			c := c
+
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				config := fmt.Sprintf(`
+				provider "wirtual" {
+				}
+				resource "wirtual_agent" "dev" {
+					os = "linux"
+					arch = "amd64"
+				}
+				resource "wirtual_app" "code-server" {
+					agent_id = wirtual_agent.dev.id
+					slug = "code-server"
+					url = "http://localhost:13337"
+					healthcheck {
+						%s
+					}
+				}
+				`, c.healthcheck)
+
+				checkFn := func(state *terraform.State) error {
+					require.Len(t, state.Modules, 1)
+					require.Len(t, state.Modules[0].Resources, 2)
+					resource := state.Modules[0].Resources["wirtual_app.code-server"]
+					require.NotNil(t, resource)
+					for key, expected := range c.expectValues {
+						require.Equal(t, expected, resource.Primary.Attributes[key], key)
+					}
+					return nil
+				}
+				if c.expectError != nil {
+					checkFn = nil
+				}
+
+				resource.Test(t, resource.TestCase{
+					ProtoV6ProviderFactories: wirtualFactory(),
+					IsUnitTest:               true,
+					Steps: []resource.TestStep{{
+						Config:      config,
+						Check:       checkFn,
+						ExpectError: c.expectError,
+					}},
+				})
+			})
+		}
+	})
+
+	t.Run("AccessURL", func(t *testing.T) {
+		t.Parallel()
+
+		cases := []struct {
+			name         string
+			buildContext string
+			attributes   string
+			expectValue  string
+			expectError  *regexp.Regexp
+		}{
+			{
+				name:        "Path",
+				attributes:  `url = "http://localhost:13337"`,
+				expectValue: "https://wirtual.example.com:8443/@jdoe/dev/apps/code-server/",
+			},
+			{
+				name: "PathWithAgent",
+				attributes: `
+					url = "http://localhost:13337"
+					agent_name = "main"`,
+				expectValue: "https://wirtual.example.com:8443/@jdoe/dev.main/apps/code-server/",
+			},
+			{
+				name: "Subdomain",
+				attributes: `
+					url = "http://localhost:13337"
+					agent_name = "main"
+					subdomain = true`,
+				expectValue: "https://code-server--main--dev--jdoe.apps.example.com:8443/",
+			},
+			{
+				name:         "SubdomainWithoutWildcard",
+				buildContext: "workspace:\n  name: dev\nowner:\n  name: jdoe\n",
+				attributes: `
+					url = "http://localhost:13337"
+					subdomain = true`,
+				expectValue: "",
+			},
+			{
+				name:         "InvalidWildcard",
+				buildContext: "wildcard_access_url: apps.example.com\n",
+				attributes:   `url = "http://localhost:13337"`,
+				expectError:  regexp.MustCompile(`Invalid WIRTUAL_WILDCARD_ACCESS_URL`),
+			},
+			{
+				name: "External",
+				attributes: `
+					url = "https://docs.example.com"
+					external = true`,
+				expectValue: "https://docs.example.com",
+			},
+			{
+				name:        "Command",
+				attributes:  `command = "htop"`,
+				expectValue: "",
+			},
+		}
+
+		for _, c := range cases {
+			c := c
+
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				buildContext := c.buildContext
+				if buildContext == "" {
+					buildContext = "workspace:\n  name: dev\nowner:\n  name: jdoe\nwildcard_access_url: \"*.apps.example.com\"\n"
+				}
+				buildContextFile := filepath.Join(t.TempDir(), "build.yaml")
+				require.NoError(t, os.WriteFile(buildContextFile, []byte(buildContext), 0o600))
+				config := fmt.Sprintf(`
+				provider "wirtual" {
+					url = "https://wirtual.example.com:8443"
+					build_context_file = %q
+				}
+				resource "wirtual_agent" "main" {
+					os = "linux"
+					arch = "amd64"
+				}
+				resource "wirtual_app" "code-server" {
+					agent_id = wirtual_agent.main.id
+					slug = "code-server"
+					%s
+				}
+				`, buildContextFile, c.attributes)
+
+				checkFn := func(state *terraform.State) error {
+					require.Len(t, state.Modules, 1)
+					resource := state.Modules[0].Resources["wirtual_app.code-server"]
+					require.NotNil(t, resource)
+					require.Equal(t, c.expectValue, resource.Primary.Attributes["access_url"])
+					return nil
+				}
+				if c.expectError != nil {
+					checkFn = nil
+				}
+
+				resource.Test(t, resource.TestCase{
+					ProtoV6ProviderFactories: wirtualFactory(),
+					IsUnitTest:               true,
 					Steps: []resource.TestStep{{
 						Config:      config,
 						Check:       checkFn,
@@ -249,6 +620,140 @@ func TestApp(t *testing.T) {
 		}
 	})
 
+	t.Run("AccessURLUpdatesInPlace", func(t *testing.T) {
+		t.Parallel()
+
+		buildContextFile := filepath.Join(t.TempDir(), "build.yaml")
+		writeBuildContext := func(workspace string) {
+			require.NoError(t, os.WriteFile(buildContextFile, []byte("workspace:\n  name: "+workspace+"\nowner:\n  name: jdoe\n"), 0o600))
+		}
+		config := fmt.Sprintf(`
+		provider "wirtual" {
+			url = "https://wirtual.example.com"
+			build_context_file = %q
+		}
+		resource "wirtual_agent" "dev" {
+			os = "linux"
+			arch = "amd64"
+		}
+		resource "wirtual_app" "code-server" {
+			agent_id = wirtual_agent.dev.id
+			slug = "code-server"
+			url = "http://localhost:13337"
+		}
+		`, buildContextFile)
+
+		var id string
+		resource.Test(t, resource.TestCase{
+			ProtoV6ProviderFactories: wirtualFactory(),
+			IsUnitTest:               true,
+			Steps: []resource.TestStep{{
+				PreConfig: func() { writeBuildContext("dev") },
+				Config:    config,
+				Check: func(state *terraform.State) error {
+					resource := state.Modules[0].Resources["wirtual_app.code-server"]
+					require.NotNil(t, resource)
+					require.Equal(t, "https://wirtual.example.com/@jdoe/dev/apps/code-server/", resource.Primary.Attributes["access_url"])
+					id = resource.Primary.ID
+					return nil
+				},
+			}, {
+				PreConfig: func() { writeBuildContext("renamed") },
+				Config:    config,
+				Check: func(state *terraform.State) error {
+					resource := state.Modules[0].Resources["wirtual_app.code-server"]
+					require.NotNil(t, resource)
+					require.Equal(t, "https://wirtual.example.com/@jdoe/renamed/apps/code-server/", resource.Primary.Attributes["access_url"])
+					require.Equal(t, id, resource.Primary.ID, "app was replaced")
+					return nil
+				},
+			}},
+		})
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		t.Parallel()
+
+		cases := []struct {
+			name        string
+			attributes  string
+			expectError *regexp.Regexp
+		}{
+			{
+				name:        "NoURLOrCommand",
+				attributes:  `display_name = "Nothing"`,
+				expectError: regexp.MustCompile(`one of "url" or "command" must be set`),
+			},
+			{
+				name:        "NonHTTPURL",
+				attributes:  `url = "ftp://localhost:21"`,
+				expectError: regexp.MustCompile(`app url "ftp://localhost:21" must be an http:// or https:// URL with a host`),
+			},
+			{
+				name:        "URLWithoutHost",
+				attributes:  `url = "localhost:8080"`,
+				expectError: regexp.MustCompile(`app url "localhost:8080" must be an http:// or https:// URL with a host`),
+			},
+			{
+				name: "HealthcheckSchemeMismatch",
+				attributes: `
+					url = "http://localhost:8080"
+					healthcheck {
+						url = "https://localhost:8080/healthz"
+						interval = 5
+						threshold = 6
+					}`,
+				expectError: regexp.MustCompile(`healthcheck url "https://localhost:8080/healthz" must use the same scheme as the app url`),
+			},
+			{
+				name: "ExternalHidden",
+				attributes: `
+					url = "https://example.com"
+					external = true
+					hidden = true`,
+				expectError: regexp.MustCompile(`external apps cannot be hidden`),
+			},
+			{
+				name: "ExternalCustomScheme",
+				attributes: `
+					url = "vscode://file/workspace"
+					external = true`,
+			},
+		}
+
+		for _, c := range cases {
+			c := c
+
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				resource.Test(t, resource.TestCase{
+					ProtoV6ProviderFactories: wirtualFactory(),
+					IsUnitTest:               true,
+					Steps: []resource.TestStep{{
+						Config: fmt.Sprintf(`
+						provider "wirtual" {
+						}
+						resource "wirtual_agent" "dev" {
+							os = "linux"
+							arch = "amd64"
+						}
+						resource "wirtual_app" "test" {
+							agent_id = wirtual_agent.dev.id
+							slug = "test"
+							%s
+						}
+						`, c.attributes),
+						ExpectError: c.expectError,
+						PlanOnly:    true,
+						// Plans that succeed create the resources.
+						ExpectNonEmptyPlan: c.expectError == nil,
+					}},
+				})
+			})
+		}
+	})
+
 	t.Run("Hidden", func(t *testing.T) {
 		t.Parallel()
 
@@ -268,8 +773,7 @@ func TestApp(t *testing.T) {
 				agent_id = wirtual_agent.dev.id
 				slug = "test"
 				display_name = "Testing"
-				url = "https://google.com"
-				external = true
+				url = "http://localhost:8080"
 				hidden = true
 			}
 			`,
@@ -298,10 +802,8 @@ func TestApp(t *testing.T) {
 			t.Run(tc.name, func(t *testing.T) {
 				t.Parallel()
 				resource.Test(t, resource.TestCase{
-					Providers: map[string]*schema.Provider{
-						"wirtual": provider.New(),
-					},
-					IsUnitTest: true,
+					ProtoV6ProviderFactories: wirtualFactory(),
+					IsUnitTest:               true,
 					Steps: []resource.TestStep{{
 						Config: tc.config,
 						Check: func(state *terraform.State) error {
@@ -319,4 +821,85 @@ func TestApp(t *testing.T) {
 		}
 	})
 
+	t.Run("UpdateInPlace", func(t *testing.T) {
+		t.Parallel()
+
+		config := func(displayName, icon string, order int) string {
+			return fmt.Sprintf(`
+			provider "wirtual" {}
+			resource "wirtual_agent" "dev" {
+				os = "linux"
+				arch = "amd64"
+			}
+			resource "wirtual_app" "code-server" {
+				agent_id = wirtual_agent.dev.id
+				slug = "code-server"
+				display_name = %q
+				icon = %q
+				url = "http://localhost:13337"
+				order = %d
+			}
+			`, displayName, icon, order)
+		}
+
+		var id string
+		resource.Test(t, resource.TestCase{
+			ProtoV6ProviderFactories: wirtualFactory(),
+			IsUnitTest:               true,
+			Steps: []resource.TestStep{{
+				Config: config("code-server", "/icon/code.svg", 1),
+				Check: func(state *terraform.State) error {
+					id = state.Modules[0].Resources["wirtual_app.code-server"].Primary.ID
+					return nil
+				},
+			}, {
+				Config: config("VS Code", "/icon/vscode.svg", 2),
+				Check: func(state *terraform.State) error {
+					resource := state.Modules[0].Resources["wirtual_app.code-server"]
+					require.NotNil(t, resource)
+					require.Equal(t, id, resource.Primary.ID, "app was replaced")
+					require.Equal(t, "VS Code", resource.Primary.Attributes["display_name"])
+					require.Equal(t, "/icon/vscode.svg", resource.Primary.Attributes["icon"])
+					require.Equal(t, "2", resource.Primary.Attributes["order"])
+					return nil
+				},
+			}},
+		})
+	})
+
+	t.Run("HiddenWarnings", func(t *testing.T) {
+		t.Parallel()
+		for _, tc := range []struct {
+			Attribute string
+			Value     tftypes.Value
+		}{{
+			Attribute: "display_name",
+			Value:     tftypes.NewValue(tftypes.String, "code-server"),
+		}, {
+			Attribute: "icon",
+			Value:     tftypes.NewValue(tftypes.String, "/icon/code.svg"),
+		}, {
+			Attribute: "order",
+			Value:     tftypes.NewValue(tftypes.Number, 1),
+		}, {
+			Attribute: "group",
+			Value:     tftypes.NewValue(tftypes.String, "Editors"),
+		}} {
+			tc := tc
+			t.Run(tc.Attribute, func(t *testing.T) {
+				t.Parallel()
+				diags := planDiagnostics(t, "wirtual_app", map[string]tftypes.Value{
+					"agent_id":   tftypes.NewValue(tftypes.String, "agent"),
+					"slug":       tftypes.NewValue(tftypes.String, "code-server"),
+					"url":        tftypes.NewValue(tftypes.String, "http://localhost:13337"),
+					"hidden":     tftypes.NewValue(tftypes.Bool, true),
+					tc.Attribute: tc.Value,
+				})
+				require.Len(t, diags, 1)
+				require.Equal(t, tfprotov6.DiagnosticSeverityWarning, diags[0].Severity)
+				require.Equal(t, "`"+tc.Attribute+"` set when app is hidden", diags[0].Summary)
+				require.Equal(t, tftypes.NewAttributePath().WithAttributeName(tc.Attribute), diags[0].Attribute)
+			})
+		}
+	})
 }
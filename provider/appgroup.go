@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"golang.org/x/xerrors"
+)
+
+func appGroupResource() *schema.Resource {
+	return &schema.Resource{
+		SchemaVersion: 1,
+
+		Description: "Use this resource to customize how a group of apps is displayed in the dashboard. " +
+			"Apps are added to the group by setting their `group` attribute to the `name` of this resource.",
+		CreateContext: func(_ context.Context, rd *schema.ResourceData, _ interface{}) diag.Diagnostics {
+			rd.SetId(uuid.NewString())
+			return nil
+		},
+		ReadContext:   schema.NoopContext,
+		UpdateContext: schema.NoopContext,
+		DeleteContext: schema.NoopContext,
+		Schema: map[string]*schema.Schema{
+			"agent_id": {
+				Type:        schema.TypeString,
+				Description: "The `id` property of a `wirtual_agent` resource to associate with.",
+				ForceNew:    true,
+				Required:    true,
+			},
+			"name": {
+				Type:         schema.TypeString,
+				Description:  "The name of the group, referenced by the `group` attribute of `wirtual_app` resources.",
+				ForceNew:     true,
+				Required:     true,
+				ValidateFunc: validation.StringIsNotWhiteSpace,
+			},
+			"display_name": {
+				Type:        schema.TypeString,
+				Description: "A display name to identify the group. Defaults to the name.",
+				Optional:    true,
+			},
+			"icon": {
+				Type: schema.TypeString,
+				Description: "A URL to an icon that will display in the dashboard. View built-in " +
+					"icons [here](https://github.com/wirtualdev/wirtual/tree/main/site/static/icon). Use a " +
+					"built-in icon with `\"${data.wirtual_workspace.me.access_url}/icon/<path>\"`.",
+				Optional: true,
+				ValidateFunc: func(i interface{}, _ string) ([]string, []error) {
+					s, ok := i.(string)
+					if !ok {
+						return nil, []error{xerrors.Errorf("expected string, got %T", i)}
+					}
+					_, err := url.Parse(s)
+					if err != nil {
+						return nil, []error{err}
+					}
+					return nil, nil
+				},
+			},
+			"order": {
+				Type:        schema.TypeInt,
+				Description: "The order determines the position of the group in the UI presentation. The lowest order is shown first and groups with equal order are sorted by name (ascending order).",
+				Optional:    true,
+			},
+		},
+	}
+}
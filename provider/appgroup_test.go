@@ -0,0 +1,83 @@
+package provider_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAppGroup(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
+		Steps: []resource.TestStep{{
+			Config: `
+			provider "wirtual" {
+			}
+			resource "wirtual_agent" "dev" {
+				os = "linux"
+				arch = "amd64"
+			}
+			resource "wirtual_app_group" "tools" {
+				agent_id = wirtual_agent.dev.id
+				name = "tools"
+				display_name = "Developer Tools"
+				icon = "/icon/tools.svg"
+				order = 2
+			}
+			resource "wirtual_app" "htop" {
+				agent_id = wirtual_agent.dev.id
+				slug = "htop"
+				command = "htop"
+				group = wirtual_app_group.tools.name
+			}
+			`,
+			Check: func(state *terraform.State) error {
+				require.Len(t, state.Modules, 1)
+				require.Len(t, state.Modules[0].Resources, 3)
+				group := state.Modules[0].Resources["wirtual_app_group.tools"]
+				require.NotNil(t, group)
+				t.Logf("app group attributes: %#v", group.Primary.Attributes)
+				for key, expected := range map[string]string{
+					"name":         "tools",
+					"display_name": "Developer Tools",
+					"icon":         "/icon/tools.svg",
+					"order":        "2",
+				} {
+					require.Equal(t, expected, group.Primary.Attributes[key])
+				}
+				app := state.Modules[0].Resources["wirtual_app.htop"]
+				require.NotNil(t, app)
+				require.Equal(t, "tools", app.Primary.Attributes["group"])
+				return nil
+			},
+		}},
+	})
+}
+
+func TestAppGroupEmptyName(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
+		Steps: []resource.TestStep{{
+			Config: `
+			provider "wirtual" {
+			}
+			resource "wirtual_app_group" "tools" {
+				agent_id = "some id"
+				name = " "
+			}
+			`,
+			ExpectError: regexp.MustCompile(`expected "name" to not be an empty string or whitespace`),
+			PlanOnly:    true,
+		}},
+	})
+}
@@ -0,0 +1,306 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"gopkg.in/yaml.v3"
+
+	"github.com/wirtualdev/terraform-provider-wirtual/provider/helpers"
+)
+
+const (
+	parameterEnvironmentVariablePrefix         = "WIRTUAL_PARAMETER_"
+	previousParameterEnvironmentVariablePrefix = "WIRTUAL_PARAMETER_PREVIOUS_"
+	externalAuthEnvironmentVariablePrefix      = "WIRTUAL_EXTERNAL_AUTH_ACCESS_TOKEN_"
+	gitAuthEnvironmentVariablePrefix           = "WIRTUAL_GIT_AUTH_ACCESS_TOKEN_"
+	agentScriptEnvironmentVariablePrefix       = "WIRTUAL_AGENT_SCRIPT_"
+)
+
+// buildContext is a snapshot of the workspace build inputs passed to the
+// provider by the Wirtual provisioner. It is loaded once when the provider is
+// configured, so every resource and data source sees the same values.
+type buildContext struct {
+	// BuildID is only set during a workspace build. Outside of a build
+	// (e.g. a local `terraform plan`) missing inputs are filled with defaults.
+	BuildID    string
+	Transition string
+	Workspace  buildContextWorkspace
+	Owner      buildContextOwner
+	Template   buildContextTemplate
+
+	// Parameters holds parameter values keyed by the hashed suffix of
+	// ParameterEnvironmentVariable.
+	Parameters map[string]string
+	// PreviousParameters holds the parameter values of the previous build,
+	// keyed like Parameters.
+	PreviousParameters map[string]string
+	// ExternalAuth holds access tokens keyed by external auth provider ID.
+	ExternalAuth map[string]string
+	// GitAuth holds access tokens keyed by git auth provider ID.
+	GitAuth map[string]string
+	// AgentScripts holds agent init scripts keyed by "<os>_<arch>".
+	AgentScripts map[string]string
+	// AgentBinaryPath overrides the path of the agent binary relative to the
+	// access URL in agent init scripts.
+	AgentBinaryPath string
+	// WildcardAccessURL is the hostname template, e.g. "*.apps.example.com",
+	// that subdomain apps are served from.
+	WildcardAccessURL string
+}
+
+type buildContextWorkspace struct {
+	ID   string `json:"id" yaml:"id"`
+	Name string `json:"name" yaml:"name"`
+}
+
+type buildContextOwner struct {
+	ID              string   `json:"id" yaml:"id"`
+	Name            string   `json:"name" yaml:"name"`
+	FullName        string   `json:"full_name" yaml:"full_name"`
+	Email           string   `json:"email" yaml:"email"`
+	Groups          []string `json:"groups" yaml:"groups"`
+	SSHPublicKey    string   `json:"ssh_public_key" yaml:"ssh_public_key"`
+	SSHPrivateKey   string   `json:"ssh_private_key" yaml:"ssh_private_key"`
+	SessionToken    string   `json:"session_token" yaml:"session_token"`
+	OIDCAccessToken string   `json:"oidc_access_token" yaml:"oidc_access_token"`
+}
+
+type buildContextTemplate struct {
+	ID      string `json:"id" yaml:"id"`
+	Name    string `json:"name" yaml:"name"`
+	Version string `json:"version" yaml:"version"`
+}
+
+// buildContextFile is the on-disk representation of a build context. Unlike
+// the environment, parameters are keyed by their plain name.
+type buildContextFile struct {
+	BuildID            string                `json:"build_id" yaml:"build_id"`
+	Transition         string                `json:"transition" yaml:"transition"`
+	Workspace          buildContextWorkspace `json:"workspace" yaml:"workspace"`
+	Owner              buildContextOwner     `json:"owner" yaml:"owner"`
+	Template           buildContextTemplate  `json:"template" yaml:"template"`
+	Parameters         map[string]string     `json:"parameters" yaml:"parameters"`
+	PreviousParameters map[string]string     `json:"previous_parameters" yaml:"previous_parameters"`
+	ExternalAuth       map[string]string     `json:"external_auth" yaml:"external_auth"`
+	GitAuth            map[string]string     `json:"git_auth" yaml:"git_auth"`
+	AgentScripts       map[string]string     `json:"agent_scripts" yaml:"agent_scripts"`
+	AgentBinaryPath    string                `json:"agent_binary_path" yaml:"agent_binary_path"`
+	WildcardAccessURL  string                `json:"wildcard_access_url" yaml:"wildcard_access_url"`
+}
+
+// loadBuildContext reads the build context from the file at path, if any, and
+// the environment, applies defaults and validates the result. Environment
+// variables take precedence over values from the file.
+func loadBuildContext(path string) (buildContext, diag.Diagnostics) {
+	bc := buildContext{
+		Parameters:         map[string]string{},
+		PreviousParameters: map[string]string{},
+		ExternalAuth:       map[string]string{},
+		GitAuth:            map[string]string{},
+		AgentScripts:       map[string]string{},
+	}
+	if path != "" {
+		file, err := readBuildContextFile(path)
+		if err != nil {
+			return bc, diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  "Invalid build context file",
+				Detail:   fmt.Sprintf("couldn't read build context file %q: %s", path, err),
+			}}
+		}
+		bc.BuildID = file.BuildID
+		bc.Transition = file.Transition
+		bc.Workspace = file.Workspace
+		bc.Owner = file.Owner
+		bc.Template = file.Template
+		for name, value := range file.Parameters {
+			bc.Parameters[parameterKey(name)] = value
+		}
+		for name, value := range file.PreviousParameters {
+			bc.PreviousParameters[parameterKey(name)] = value
+		}
+		maps.Copy(bc.ExternalAuth, file.ExternalAuth)
+		maps.Copy(bc.GitAuth, file.GitAuth)
+		maps.Copy(bc.AgentScripts, file.AgentScripts)
+		bc.AgentBinaryPath = file.AgentBinaryPath
+		bc.WildcardAccessURL = file.WildcardAccessURL
+	}
+
+	diags := bc.loadEnv()
+	bc.applyDefaults()
+	return bc, append(diags, bc.validate()...)
+}
+
+// readBuildContextFile decodes a JSON or YAML build context file. Unknown
+// fields are rejected so typos don't silently fall back to defaults.
+func readBuildContextFile(path string) (buildContextFile, error) {
+	var file buildContextFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&file)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&file)
+		if errors.Is(err, io.EOF) {
+			err = nil // empty file
+		}
+	}
+	return file, err
+}
+
+// loadEnv overrides the build context with any WIRTUAL_* environment
+// variables that are set.
+func (bc *buildContext) loadEnv() diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for name, field := range map[string]*string{
+		"WIRTUAL_WORKSPACE_BUILD_ID":                &bc.BuildID,
+		"WIRTUAL_WORKSPACE_TRANSITION":              &bc.Transition,
+		"WIRTUAL_WORKSPACE_ID":                      &bc.Workspace.ID,
+		"WIRTUAL_WORKSPACE_NAME":                    &bc.Workspace.Name,
+		"WIRTUAL_WORKSPACE_OWNER_ID":                &bc.Owner.ID,
+		"WIRTUAL_WORKSPACE_OWNER":                   &bc.Owner.Name,
+		"WIRTUAL_WORKSPACE_OWNER_NAME":              &bc.Owner.FullName,
+		"WIRTUAL_WORKSPACE_OWNER_EMAIL":             &bc.Owner.Email,
+		"WIRTUAL_WORKSPACE_OWNER_SSH_PUBLIC_KEY":    &bc.Owner.SSHPublicKey,
+		"WIRTUAL_WORKSPACE_OWNER_SSH_PRIVATE_KEY":   &bc.Owner.SSHPrivateKey,
+		"WIRTUAL_WORKSPACE_OWNER_SESSION_TOKEN":     &bc.Owner.SessionToken,
+		"WIRTUAL_WORKSPACE_OWNER_OIDC_ACCESS_TOKEN": &bc.Owner.OIDCAccessToken,
+		"WIRTUAL_WORKSPACE_TEMPLATE_ID":             &bc.Template.ID,
+		"WIRTUAL_WORKSPACE_TEMPLATE_NAME":           &bc.Template.Name,
+		"WIRTUAL_WORKSPACE_TEMPLATE_VERSION":        &bc.Template.Version,
+		"WIRTUAL_AGENT_BINARY_PATH":                 &bc.AgentBinaryPath,
+		"WIRTUAL_WILDCARD_ACCESS_URL":               &bc.WildcardAccessURL,
+	} {
+		*field = helpers.OptionalEnvOrDefault(name, *field)
+	}
+
+	if groups := os.Getenv("WIRTUAL_WORKSPACE_OWNER_GROUPS"); groups != "" {
+		bc.Owner.Groups = nil
+		if err := json.Unmarshal([]byte(groups), &bc.Owner.Groups); err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Invalid WIRTUAL_WORKSPACE_OWNER_GROUPS",
+				Detail:   fmt.Sprintf("couldn't parse owner groups %q as a JSON array of strings: %s", groups, err),
+			})
+		}
+	}
+
+	for name, value := range helpers.PrefixedEnv(parameterEnvironmentVariablePrefix) {
+		// Previous values share the prefix of current values.
+		if key, ok := strings.CutPrefix(parameterEnvironmentVariablePrefix+name, previousParameterEnvironmentVariablePrefix); ok {
+			bc.PreviousParameters[key] = value
+			continue
+		}
+		bc.Parameters[name] = value
+	}
+	maps.Copy(bc.ExternalAuth, helpers.PrefixedEnv(externalAuthEnvironmentVariablePrefix))
+	maps.Copy(bc.GitAuth, helpers.PrefixedEnv(gitAuthEnvironmentVariablePrefix))
+	maps.Copy(bc.AgentScripts, helpers.PrefixedEnv(agentScriptEnvironmentVariablePrefix))
+	return diags
+}
+
+// applyDefaults fills in values that were provided by neither the build
+// context file nor the environment.
+func (bc *buildContext) applyDefaults() {
+	for field, defaultValue := range map[*string]string{
+		&bc.Transition:     "start", // Default to start!
+		&bc.Workspace.ID:   uuid.NewString(),
+		&bc.Workspace.Name: "default",
+		&bc.Owner.ID:       uuid.Nil.String(),
+		&bc.Owner.Name:     "default",
+		&bc.Owner.FullName: "default",
+		&bc.Owner.Email:    "default@example.com",
+	} {
+		if *field == "" {
+			*field = defaultValue
+		}
+	}
+}
+
+// validate reports inputs that are malformed, or missing during a workspace
+// build.
+func (bc buildContext) validate() diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	switch bc.Transition {
+	case "start", "stop", "delete":
+	default:
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Invalid WIRTUAL_WORKSPACE_TRANSITION",
+			Detail:   fmt.Sprintf("transition must be one of \"start\", \"stop\" or \"delete\", got %q", bc.Transition),
+		})
+	}
+
+	if bc.WildcardAccessURL != "" &&
+		(!strings.HasPrefix(bc.WildcardAccessURL, "*") ||
+			strings.Count(bc.WildcardAccessURL, "*") != 1 ||
+			strings.ContainsAny(bc.WildcardAccessURL, "/ ")) {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Invalid WIRTUAL_WILDCARD_ACCESS_URL",
+			Detail:   fmt.Sprintf("wildcard access URL must be a hostname starting with a single \"*\", e.g. \"*.apps.example.com\", got %q", bc.WildcardAccessURL),
+		})
+	}
+
+	if bc.BuildID == "" {
+		return diags
+	}
+	for _, required := range []struct {
+		name, value string
+	}{
+		{"WIRTUAL_WORKSPACE_TEMPLATE_ID", bc.Template.ID},
+		{"WIRTUAL_WORKSPACE_TEMPLATE_NAME", bc.Template.Name},
+		{"WIRTUAL_WORKSPACE_TEMPLATE_VERSION", bc.Template.Version},
+	} {
+		if required.value == "" {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("%s is required", required.name),
+				Detail:   "This value must be provided during a workspace build.",
+			})
+		}
+	}
+	return diags
+}
+
+// parameter returns the value supplied for the named parameter, if any.
+func (bc buildContext) parameter(name string) (string, bool) {
+	value, ok := bc.Parameters[parameterKey(name)]
+	return value, ok
+}
+
+// previousParameter returns the value the named parameter had in the previous
+// build, if any.
+func (bc buildContext) previousParameter(name string) (string, bool) {
+	value, ok := bc.PreviousParameters[parameterKey(name)]
+	return value, ok
+}
+
+// parameterKey returns the key of the named parameter in
+// buildContext.Parameters.
+func parameterKey(name string) string {
+	return strings.TrimPrefix(ParameterEnvironmentVariable(name), parameterEnvironmentVariablePrefix)
+}
+
+// agentScript returns the agent init script for the given platform, if any.
+func (bc buildContext) agentScript(operatingSystem, arch string) string {
+	return bc.AgentScripts[fmt.Sprintf("%s_%s", operatingSystem, arch)]
+}
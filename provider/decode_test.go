@@ -23,6 +23,8 @@ func TestDecode(t *testing.T) {
 				"min_disabled": false,
 				"max":          5,
 				"max_disabled": true,
+				"step":         0.5,
+				"precision":    1,
 			},
 		},
 	}
@@ -31,8 +33,11 @@ func TestDecode(t *testing.T) {
 	err := mapstructure.Decode(aMap, &param)
 	require.NoError(t, err)
 	assert.Equal(t, displayName, param.DisplayName)
-	assert.Equal(t, 5, param.Validation[0].Max)
+	assert.Equal(t, 5.0, param.Validation[0].Max)
 	assert.True(t, param.Validation[0].MaxDisabled)
-	assert.Equal(t, 0, param.Validation[0].Min)
+	assert.Equal(t, 0.0, param.Validation[0].Min)
 	assert.False(t, param.Validation[0].MinDisabled)
+	assert.Equal(t, 0.5, param.Validation[0].Step)
+	require.NotNil(t, param.Validation[0].Precision)
+	assert.Equal(t, 1, *param.Validation[0].Precision)
 }
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"golang.org/x/xerrors"
+)
+
+func devcontainerResource() *schema.Resource {
+	return &schema.Resource{
+		SchemaVersion: 1,
+
+		Description: "Use this resource to define a dev container that the agent should know of and optionally start. " +
+			"Dev containers are shown in the dashboard as sub-agents of the `wirtual_agent` they are associated with.",
+		CreateContext: func(_ context.Context, rd *schema.ResourceData, _ interface{}) diag.Diagnostics {
+			rd.SetId(uuid.NewString())
+			return nil
+		},
+		ReadContext:   schema.NoopContext,
+		UpdateContext: schema.NoopContext,
+		DeleteContext: schema.NoopContext,
+		Schema: map[string]*schema.Schema{
+			"agent_id": {
+				Type:        schema.TypeString,
+				Description: "The `id` property of a `wirtual_agent` resource to associate with.",
+				ForceNew:    true,
+				Required:    true,
+			},
+			"workspace_folder": {
+				Type:         schema.TypeString,
+				Description:  "The absolute path of the folder inside the agent that contains the project to open in the dev container.",
+				ForceNew:     true,
+				Required:     true,
+				ValidateFunc: validateDevcontainerWorkspaceFolder,
+			},
+			"config_path": {
+				Type: schema.TypeString,
+				Description: "The path of the `devcontainer.json` file to use, either absolute or relative to " +
+					"`workspace_folder`. Defaults to `.devcontainer/devcontainer.json` or `.devcontainer.json` " +
+					"in `workspace_folder`.",
+				ForceNew:     true,
+				Optional:     true,
+				ValidateFunc: validation.StringIsNotWhiteSpace,
+			},
+			"auto_start": {
+				Type:        schema.TypeBool,
+				Description: "Whether the agent should start the dev container when the workspace starts. Defaults to `true`.",
+				Default:     true,
+				Optional:    true,
+			},
+		},
+	}
+}
+
+// validateDevcontainerWorkspaceFolder ensures the workspace folder of a
+// dev container is an absolute path, on either Unix or Windows agents.
+func validateDevcontainerWorkspaceFolder(i interface{}, _ string) ([]string, []error) {
+	folder, ok := i.(string)
+	if !ok {
+		return nil, []error{xerrors.Errorf("expected string, got %T", i)}
+	}
+	if strings.TrimSpace(folder) == "" {
+		return nil, []error{xerrors.New("workspace_folder must not be empty")}
+	}
+	if !strings.HasPrefix(folder, "/") && !windowsAbsolutePathRegex.MatchString(folder) {
+		return nil, []error{xerrors.Errorf("workspace_folder must be an absolute path, got %q", folder)}
+	}
+	return nil, nil
+}
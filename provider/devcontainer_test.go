@@ -0,0 +1,117 @@
+package provider_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestDevcontainer(t *testing.T) {
+	t.Parallel()
+
+	var id string
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
+		Steps: []resource.TestStep{{
+			Config: `
+			provider "wirtual" {
+			}
+			resource "wirtual_devcontainer" "example" {
+				agent_id = "some id"
+				workspace_folder = "/workspace/wirtual"
+				config_path = ".devcontainer/devcontainer.json"
+			}
+			`,
+			Check: func(state *terraform.State) error {
+				require.Len(t, state.Modules, 1)
+				require.Len(t, state.Modules[0].Resources, 1)
+				devcontainer := state.Modules[0].Resources["wirtual_devcontainer.example"]
+				require.NotNil(t, devcontainer)
+				t.Logf("devcontainer attributes: %#v", devcontainer.Primary.Attributes)
+				for key, expected := range map[string]string{
+					"agent_id":         "some id",
+					"workspace_folder": "/workspace/wirtual",
+					"config_path":      ".devcontainer/devcontainer.json",
+					"auto_start":       "true",
+				} {
+					require.Equal(t, expected, devcontainer.Primary.Attributes[key])
+				}
+				id = devcontainer.Primary.ID
+				return nil
+			},
+		}, {
+			// Toggling auto_start updates the dev container in place.
+			Config: `
+			provider "wirtual" {
+			}
+			resource "wirtual_devcontainer" "example" {
+				agent_id = "some id"
+				workspace_folder = "/workspace/wirtual"
+				config_path = ".devcontainer/devcontainer.json"
+				auto_start = false
+			}
+			`,
+			Check: func(state *terraform.State) error {
+				devcontainer := state.Modules[0].Resources["wirtual_devcontainer.example"]
+				require.NotNil(t, devcontainer)
+				require.Equal(t, "false", devcontainer.Primary.Attributes["auto_start"])
+				require.Equal(t, id, devcontainer.Primary.ID)
+				return nil
+			},
+		}},
+	})
+}
+
+func TestDevcontainerValidation(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		Name   string
+		Config string
+		Error  string
+	}{{
+		Name: "RelativeWorkspaceFolder",
+		Config: `
+			resource "wirtual_devcontainer" "example" {
+				agent_id = "some id"
+				workspace_folder = "workspace/wirtual"
+			}`,
+		Error: `workspace_folder must be an absolute path`,
+	}, {
+		Name: "EmptyWorkspaceFolder",
+		Config: `
+			resource "wirtual_devcontainer" "example" {
+				agent_id = "some id"
+				workspace_folder = " "
+			}`,
+		Error: `workspace_folder must not be empty`,
+	}, {
+		Name: "EmptyConfigPath",
+		Config: `
+			resource "wirtual_devcontainer" "example" {
+				agent_id = "some id"
+				workspace_folder = "C:\\workspace\\wirtual"
+				config_path = ""
+			}`,
+		Error: `expected "config_path" to not be an empty string or whitespace`,
+	}} {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: wirtualFactory(),
+				IsUnitTest:               true,
+				Steps: []resource.TestStep{{
+					Config:      tc.Config,
+					ExpectError: regexp.MustCompile(tc.Error),
+					PlanOnly:    true,
+				}},
+			})
+		})
+	}
+}
@@ -5,10 +5,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
-	"github.com/wirtualdev/terraform-provider-wirtual/provider"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
@@ -16,10 +14,8 @@ func TestEnv(t *testing.T) {
 	t.Parallel()
 
 	resource.Test(t, resource.TestCase{
-		Providers: map[string]*schema.Provider{
-			"wirtual": provider.New(),
-		},
-		IsUnitTest: true,
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
 		Steps: []resource.TestStep{{
 			Config: `
 			provider "wirtual" {
@@ -53,10 +49,8 @@ func TestEnvEmptyValue(t *testing.T) {
 	t.Parallel()
 
 	resource.Test(t, resource.TestCase{
-		Providers: map[string]*schema.Provider{
-			"wirtual": provider.New(),
-		},
-		IsUnitTest: true,
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
 		Steps: []resource.TestStep{{
 			Config: `
 			provider "wirtual" {
@@ -89,10 +83,8 @@ func TestEnvBadName(t *testing.T) {
 	t.Parallel()
 
 	resource.Test(t, resource.TestCase{
-		Providers: map[string]*schema.Provider{
-			"wirtual": provider.New(),
-		},
-		IsUnitTest: true,
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
 		Steps: []resource.TestStep{{
 			Config: `
 			provider "wirtual" {
@@ -111,10 +103,8 @@ func TestEnvNoName(t *testing.T) {
 	t.Parallel()
 
 	resource.Test(t, resource.TestCase{
-		Providers: map[string]*schema.Provider{
-			"wirtual": provider.New(),
-		},
-		IsUnitTest: true,
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
 		Steps: []resource.TestStep{{
 			Config: `
 			provider "wirtual" {
@@ -6,9 +6,7 @@ import (
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/stretchr/testify/require"
-	"github.com/wirtualdev/terraform-provider-wirtual/provider"
 )
 
 func TestExamples(t *testing.T) {
@@ -29,10 +27,8 @@ func TestExamples(t *testing.T) {
 
 func resourceTest(t *testing.T, testDir string) {
 	resource.Test(t, resource.TestCase{
-		Providers: map[string]*schema.Provider{
-			"wirtual": provider.New(),
-		},
-		IsUnitTest: true,
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
 		Steps: []resource.TestStep{{
 			Config: mustReadFile(t, fmt.Sprintf("../examples/data-sources/%s/data-source.tf", testDir)),
 		}},
@@ -2,12 +2,10 @@ package provider
 
 import (
 	"context"
-	"fmt"
+	"reflect"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-
-	"github.com/wirtualdev/terraform-provider-wirtual/provider/helpers"
 )
 
 // externalAuthDataSource returns a schema for an external authentication data source.
@@ -23,8 +21,11 @@ func externalAuthDataSource() *schema.Resource {
 			}
 			rd.SetId(id)
 
-			accessToken := helpers.OptionalEnv(ExternalAuthAccessTokenEnvironmentVariable(id))
-			rd.Set("access_token", accessToken)
+			config, valid := i.(config)
+			if !valid {
+				return diag.Errorf("config was unexpected type %q", reflect.TypeOf(i).String())
+			}
+			rd.Set("access_token", config.BuildContext.ExternalAuth[id])
 			return nil
 		},
 		Schema: map[string]*schema.Schema{
@@ -34,9 +35,10 @@ func externalAuthDataSource() *schema.Resource {
 				Required:    true,
 			},
 			"access_token": {
-				Type:        schema.TypeString,
-				Description: "The access token returned by the external auth provider. This can be used to pre-authenticate command-line tools.",
-				Computed:    true,
+				Type: schema.TypeString,
+				Description: "The access token returned by the external auth provider. This can be used to pre-authenticate command-line tools. " +
+					"Use the `wirtual_external_auth_token` ephemeral resource to keep it out of the Terraform state.",
+				Computed: true,
 			},
 			"optional": {
 				Type:        schema.TypeBool,
@@ -48,5 +50,5 @@ func externalAuthDataSource() *schema.Resource {
 }
 
 func ExternalAuthAccessTokenEnvironmentVariable(id string) string {
-	return fmt.Sprintf("WIRTUAL_EXTERNAL_AUTH_ACCESS_TOKEN_%s", id)
+	return externalAuthEnvironmentVariablePrefix + id
 }
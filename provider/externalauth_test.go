@@ -3,10 +3,7 @@ package provider_test
 import (
 	"testing"
 
-	"github.com/wirtualdev/terraform-provider-wirtual/provider"
-
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 
 	"github.com/stretchr/testify/require"
@@ -16,10 +13,8 @@ func TestExternalAuth(t *testing.T) {
 	t.Parallel()
 
 	resource.Test(t, resource.TestCase{
-		Providers: map[string]*schema.Provider{
-			"wirtual": provider.New(),
-		},
-		IsUnitTest: true,
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
 		Steps: []resource.TestStep{{
 			Config: `
 			provider "wirtual" {
@@ -48,10 +43,8 @@ func TestOptionalExternalAuth(t *testing.T) {
 	t.Parallel()
 
 	resource.Test(t, resource.TestCase{
-		Providers: map[string]*schema.Provider{
-			"wirtual": provider.New(),
-		},
-		IsUnitTest: true,
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
 		Steps: []resource.TestStep{{
 			Config: `
 			provider "wirtual" {
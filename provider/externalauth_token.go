@@ -0,0 +1,65 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type externalAuthTokenEphemeralResource struct {
+	buildContext *buildContext
+}
+
+type externalAuthTokenModel struct {
+	ID          types.String `tfsdk:"id"`
+	AccessToken types.String `tfsdk:"access_token"`
+}
+
+var _ ephemeral.EphemeralResourceWithConfigure = &externalAuthTokenEphemeralResource{}
+
+func newExternalAuthTokenEphemeralResource() ephemeral.EphemeralResource {
+	return &externalAuthTokenEphemeralResource{}
+}
+
+func (*externalAuthTokenEphemeralResource) Metadata(_ context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_external_auth_token"
+}
+
+func (*externalAuthTokenEphemeralResource) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Use this ephemeral resource to access the token of an external auth provider without storing it in the " +
+			"Terraform plan or state. Users must still be required to authenticate with the provider through a " +
+			"`wirtual_external_auth` data source. Requires Terraform 1.10 or later.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Required:    true,
+				Description: "The ID of a configured external auth provider set up in your Wirtual deployment.",
+			},
+			"access_token": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The access token returned by the external auth provider. This can be used to pre-authenticate command-line tools.",
+			},
+		},
+	}
+}
+
+func (r *externalAuthTokenEphemeralResource) Configure(_ context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	r.buildContext = ephemeralBuildContext(req.ProviderData, &resp.Diagnostics)
+}
+
+func (r *externalAuthTokenEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	if r.buildContext == nil {
+		resp.Diagnostics.AddError("Provider not configured", "The wirtual provider must be configured before opening wirtual_external_auth_token.")
+		return
+	}
+	var model externalAuthTokenModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &model)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	model.AccessToken = types.StringValue(r.buildContext.ExternalAuth[model.ID.ValueString()])
+	resp.Diagnostics.Append(resp.Result.Set(ctx, model)...)
+}
@@ -0,0 +1,33 @@
+package provider_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalAuthToken(t *testing.T) {
+	t.Parallel()
+
+	buildContextFile := filepath.Join(t.TempDir(), "build.json")
+	require.NoError(t, os.WriteFile(buildContextFile, []byte(`{"external_auth": {"github": "token"}}`), 0o600))
+
+	typ := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"id":           tftypes.String,
+		"access_token": tftypes.String,
+	}}
+	for id, token := range map[string]string{
+		"github": "token",
+		"gitlab": "",
+	} {
+		result := openEphemeralResource(t, buildContextFile, "wirtual_external_auth_token", typ, map[string]tftypes.Value{
+			"id":           tftypes.NewValue(tftypes.String, id),
+			"access_token": tftypes.NewValue(tftypes.String, nil),
+		})
+		assertString(t, id, result["id"])
+		assertString(t, token, result["access_token"])
+	}
+}
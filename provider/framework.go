@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"reflect"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwprovider "github.com/hashicorp/terraform-plugin-framework/provider"
+	fwschema "github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-mux/tf5to6server"
+	"github.com/hashicorp/terraform-plugin-mux/tf6muxserver"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"golang.org/x/xerrors"
+)
+
+// NewServer returns a protocol version 6 provider server that muxes the
+// SDKv2 provider returned by New with the terraform-plugin-framework provider
+// serving provider-defined functions and ephemeral resources. The SDKv2 server
+// is wrapped to return all the diagnostics of CustomizeDiff funcs with plans.
+func NewServer(ctx context.Context) (func() tfprotov6.ProviderServer, error) {
+	sdkServer, err := tf5to6server.UpgradeServer(ctx, New().GRPCProvider)
+	if err != nil {
+		return nil, xerrors.Errorf("upgrade sdk provider server: %w", err)
+	}
+	muxServer, err := tf6muxserver.NewMuxServer(ctx,
+		func() tfprotov6.ProviderServer { return planDiagnosticServer{sdkServer} },
+		providerserver.NewProtocol6(&frameworkProvider{}),
+	)
+	if err != nil {
+		return nil, xerrors.Errorf("mux provider servers: %w", err)
+	}
+	return muxServer.ProviderServer, nil
+}
+
+// frameworkProvider implements the parts of the provider that are only
+// available through terraform-plugin-framework. Configuration errors are
+// reported by the SDKv2 provider.
+type frameworkProvider struct{}
+
+var (
+	_ fwprovider.ProviderWithFunctions          = &frameworkProvider{}
+	_ fwprovider.ProviderWithEphemeralResources = &frameworkProvider{}
+)
+
+func (*frameworkProvider) Metadata(_ context.Context, _ fwprovider.MetadataRequest, resp *fwprovider.MetadataResponse) {
+	resp.TypeName = "wirtual"
+}
+
+// Schema mirrors the SDKv2 provider schema, as muxed servers must agree on it.
+func (*frameworkProvider) Schema(_ context.Context, _ fwprovider.SchemaRequest, resp *fwprovider.SchemaResponse) {
+	attributes := map[string]fwschema.Attribute{}
+	for name, s := range New().Schema {
+		switch s.Type {
+		case schema.TypeString:
+			attributes[name] = fwschema.StringAttribute{
+				Description:        s.Description,
+				Optional:           s.Optional,
+				Required:           s.Required,
+				Sensitive:          s.Sensitive,
+				DeprecationMessage: s.Deprecated,
+			}
+		case schema.TypeBool:
+			attributes[name] = fwschema.BoolAttribute{
+				Description:        s.Description,
+				Optional:           s.Optional,
+				Required:           s.Required,
+				Sensitive:          s.Sensitive,
+				DeprecationMessage: s.Deprecated,
+			}
+		default:
+			resp.Diagnostics.AddError("Unsupported provider attribute type",
+				"provider attribute "+name+" has type "+s.Type.String()+" which is not mirrored in the framework provider schema")
+		}
+	}
+	resp.Schema = fwschema.Schema{Attributes: attributes}
+}
+
+// Configure loads the build context for ephemeral resources. Both muxed
+// providers are configured, so diagnostics are left to the SDKv2 provider to
+// avoid reporting them twice; ephemeral resources fail to open instead.
+func (*frameworkProvider) Configure(ctx context.Context, req fwprovider.ConfigureRequest, resp *fwprovider.ConfigureResponse) {
+	var buildContextFile types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("build_context_file"), &buildContextFile)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	file := buildContextFile.ValueString()
+	if buildContextFile.IsNull() {
+		file = os.Getenv("WIRTUAL_BUILD_CONTEXT_FILE")
+	}
+	buildContext, diags := loadBuildContext(file)
+	if diags.HasError() {
+		return
+	}
+	resp.EphemeralResourceData = config{BuildContext: buildContext}
+}
+
+func (*frameworkProvider) Resources(context.Context) []func() resource.Resource {
+	return nil
+}
+
+func (*frameworkProvider) DataSources(context.Context) []func() datasource.DataSource {
+	return nil
+}
+
+func (*frameworkProvider) Functions(context.Context) []func() function.Function {
+	return []func() function.Function{
+		newParameterEnvNameFunction,
+		newExternalAuthEnvNameFunction,
+		newIsValidAppSlugFunction,
+		newCronNextFunction,
+		newValidateParameterFunction,
+	}
+}
+
+func (*frameworkProvider) EphemeralResources(context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		newOwnerCredentialsEphemeralResource,
+		newExternalAuthTokenEphemeralResource,
+	}
+}
+
+// ephemeralBuildContext returns the build context from the provider data
+// passed to an ephemeral resource, or nil if the provider isn't configured.
+func ephemeralBuildContext(providerData any, diags *fwdiag.Diagnostics) *buildContext {
+	if providerData == nil {
+		return nil
+	}
+	config, valid := providerData.(config)
+	if !valid {
+		diags.AddError("Unexpected provider data", "config was unexpected type "+reflect.TypeOf(providerData).String())
+		return nil
+	}
+	return &config.BuildContext
+}
@@ -0,0 +1,323 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// cronNextMaxCount caps the number of times returned by cron_next.
+const cronNextMaxCount = 100
+
+type parameterEnvNameFunction struct{}
+
+func newParameterEnvNameFunction() function.Function {
+	return &parameterEnvNameFunction{}
+}
+
+func (*parameterEnvNameFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "parameter_env_name"
+}
+
+func (*parameterEnvNameFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Environment variable name for a parameter",
+		Description: "Returns the name of the environment variable Wirtual uses to pass the value of the named `wirtual_parameter` to the provider.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "name",
+				Description: "The name of the parameter.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (*parameterEnvNameFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var name string
+	resp.Error = req.Arguments.Get(ctx, &name)
+	if resp.Error != nil {
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, ParameterEnvironmentVariable(name))
+}
+
+type externalAuthEnvNameFunction struct{}
+
+func newExternalAuthEnvNameFunction() function.Function {
+	return &externalAuthEnvNameFunction{}
+}
+
+func (*externalAuthEnvNameFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "external_auth_env_name"
+}
+
+func (*externalAuthEnvNameFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Environment variable name for an external auth access token",
+		Description: "Returns the name of the environment variable Wirtual uses to pass the access token of the external auth provider with the given ID.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "id",
+				Description: "The ID of the external auth provider.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (*externalAuthEnvNameFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var id string
+	resp.Error = req.Arguments.Get(ctx, &id)
+	if resp.Error != nil {
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, ExternalAuthAccessTokenEnvironmentVariable(id))
+}
+
+type isValidAppSlugFunction struct{}
+
+func newIsValidAppSlugFunction() function.Function {
+	return &isValidAppSlugFunction{}
+}
+
+func (*isValidAppSlugFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "is_valid_app_slug"
+}
+
+func (*isValidAppSlugFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Check whether a string is a valid app slug",
+		Description: "Returns `true` if the string can be used as the `slug` of a `wirtual_app`: a valid hostname that does not contain two consecutive hyphens or start/end with a hyphen.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "slug",
+				Description: "The slug to check.",
+			},
+		},
+		Return: function.BoolReturn{},
+	}
+}
+
+func (*isValidAppSlugFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var slug string
+	resp.Error = req.Arguments.Get(ctx, &slug)
+	if resp.Error != nil {
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, appSlugRegex.MatchString(slug))
+}
+
+type cronNextFunction struct{}
+
+func newCronNextFunction() function.Function {
+	return &cronNextFunction{}
+}
+
+func (*cronNextFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "cron_next"
+}
+
+func (*cronNextFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Next run times of a cron expression",
+		Description: "Returns the next `n` times, as RFC 3339 timestamps in UTC, at which a `wirtual_script` with the given `cron` " +
+			"expression would run, after the `from` RFC 3339 timestamp. Provider functions must return the same result " +
+			"during plan and apply, so there is no default for `from`: pass `plantimestamp()` to compute the times " +
+			"after the current plan.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "expr",
+				Description: "The cron expression, in the format accepted by `wirtual_script.cron`.",
+			},
+			function.Int64Parameter{
+				Name:        "n",
+				Description: fmt.Sprintf("The number of times to return, between 1 and %d.", cronNextMaxCount),
+			},
+			function.StringParameter{
+				Name:        "from",
+				Description: "An RFC 3339 timestamp to compute the run times after.",
+			},
+		},
+		Return: function.ListReturn{ElementType: types.StringType},
+	}
+}
+
+func (*cronNextFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var (
+		expr string
+		n    int64
+		from string
+	)
+	resp.Error = req.Arguments.Get(ctx, &expr, &n, &from)
+	if resp.Error != nil {
+		return
+	}
+
+	schedule, err := ScriptCRONParser.Parse(expr)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("%s is not a valid cron expression: %s", expr, err))
+		return
+	}
+	if n < 1 || n > cronNextMaxCount {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("n must be between 1 and %d, got %d", cronNextMaxCount, n))
+		return
+	}
+
+	next, err := time.Parse(time.RFC3339, from)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(2, fmt.Sprintf("%q is not an RFC 3339 timestamp: %s", from, err))
+		return
+	}
+
+	times := make([]string, 0, n)
+	for i := int64(0); i < n; i++ {
+		next = schedule.Next(next)
+		if next.IsZero() {
+			break // The schedule never runs again.
+		}
+		times = append(times, next.UTC().Format(time.RFC3339))
+	}
+	resp.Error = resp.Result.Set(ctx, times)
+}
+
+type validateParameterFunction struct{}
+
+func newValidateParameterFunction() function.Function {
+	return &validateParameterFunction{}
+}
+
+func (*validateParameterFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "validate_parameter"
+}
+
+func (*validateParameterFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Validate a value like a wirtual_parameter would",
+		Description: "Checks `value` against the parameter `type` and the `rules` of a `wirtual_parameter` `validation` block, " +
+			"and returns `value` unchanged if it is valid. Otherwise the function fails with the same error the parameter " +
+			"would report. Supported rules are `min`, `max`, `step`, `precision`, `monotonic`, `min_items`, `max_items`, " +
+			"`unique_items`, `min_length`, `max_length`, `regex` and `error`. Wrap the call in `can()` " +
+			"to get a boolean instead.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "type",
+				Description: "The parameter type: `\"number\"`, `\"string\"`, `\"bool\"`, `\"list(string)\"`, `\"map(string)\"` or `\"object\"`.",
+			},
+			function.StringParameter{
+				Name:        "value",
+				Description: "The value to validate.",
+			},
+			function.MapParameter{
+				Name:        "rules",
+				Description: "The validation rules, keyed by the attribute names of the `validation` block.",
+				ElementType: types.StringType,
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (*validateParameterFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var (
+		typ, value string
+		rules      map[string]string
+	)
+	resp.Error = req.Arguments.Get(ctx, &typ, &value, &rules)
+	if resp.Error != nil {
+		return
+	}
+
+	validation, err := validationFromRules(rules)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(2, err.Error())
+		return
+	}
+	if diags := valueIsType(typ, value); diags.HasError() {
+		resp.Error = function.NewFuncError(diags[0].Summary)
+		return
+	}
+	if err := validation.Valid(typ, value); err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, value)
+}
+
+// validationFromRules converts the rules argument of validate_parameter to a
+// Validation.
+func validationFromRules(rules map[string]string) (Validation, error) {
+	validation := Validation{
+		MinDisabled: true,
+		MaxDisabled: true,
+	}
+
+	keys := make([]string, 0, len(rules))
+	for key := range rules {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := rules[key]
+		switch key {
+		case "min", "max", "step":
+			num, err := parseNumber(value)
+			if err != nil {
+				return validation, fmt.Errorf("rule %q must be a number, got %q", key, value)
+			}
+			switch key {
+			case "min":
+				validation.Min, validation.MinDisabled = num, false
+			case "max":
+				validation.Max, validation.MaxDisabled = num, false
+			default:
+				validation.Step = num
+			}
+		case "precision", "min_items", "max_items", "min_length", "max_length":
+			num, err := strconv.Atoi(value)
+			if err != nil {
+				return validation, fmt.Errorf("rule %q must be an integer, got %q", key, value)
+			}
+			// The same bounds as the validation block of wirtual_parameter.
+			if key == "precision" && (num < 0 || num > 15) {
+				return validation, fmt.Errorf("rule %q must be between 0 and 15, got %d", key, num)
+			}
+			if key != "precision" && num < 1 {
+				return validation, fmt.Errorf("rule %q must be at least 1, got %d", key, num)
+			}
+			switch key {
+			case "precision":
+				validation.Precision = &num
+			case "min_items":
+				validation.MinItems = num
+			case "max_items":
+				validation.MaxItems = num
+			case "min_length":
+				validation.MinLength = num
+			default:
+				validation.MaxLength = num
+			}
+		case "unique_items":
+			unique, err := strconv.ParseBool(value)
+			if err != nil {
+				return validation, fmt.Errorf("rule %q must be a bool, got %q", key, value)
+			}
+			validation.UniqueItems = unique
+		case "monotonic":
+			validation.Monotonic = value
+		case "regex":
+			validation.Regex = value
+		case "error":
+			validation.Error = value
+		default:
+			return validation, fmt.Errorf("unsupported rule %q", key)
+		}
+	}
+	return validation, nil
+}
@@ -0,0 +1,258 @@
+package provider_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wirtualdev/terraform-provider-wirtual/provider"
+)
+
+func TestFunctions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ParameterEnvName", func(t *testing.T) {
+		t.Parallel()
+		result, err := callFunction(t, "parameter_env_name", tftypes.String, tftypes.NewValue(tftypes.String, "region"))
+		require.Nil(t, err)
+		assertString(t, provider.ParameterEnvironmentVariable("region"), result)
+	})
+
+	t.Run("ExternalAuthEnvName", func(t *testing.T) {
+		t.Parallel()
+		result, err := callFunction(t, "external_auth_env_name", tftypes.String, tftypes.NewValue(tftypes.String, "github"))
+		require.Nil(t, err)
+		assertString(t, "WIRTUAL_EXTERNAL_AUTH_ACCESS_TOKEN_github", result)
+	})
+
+	t.Run("IsValidAppSlug", func(t *testing.T) {
+		t.Parallel()
+		for slug, valid := range map[string]bool{
+			"code-server":  true,
+			"code--server": false,
+			"-code":        false,
+			"Code":         false,
+		} {
+			result, err := callFunction(t, "is_valid_app_slug", tftypes.Bool, tftypes.NewValue(tftypes.String, slug))
+			require.Nil(t, err)
+			var got bool
+			require.NoError(t, result.As(&got))
+			assert.Equal(t, valid, got, slug)
+		}
+	})
+
+	t.Run("CronNext", func(t *testing.T) {
+		t.Parallel()
+		result, err := callFunction(t, "cron_next", tftypes.List{ElementType: tftypes.String},
+			tftypes.NewValue(tftypes.String, "0 30 9 * * *"),
+			tftypes.NewValue(tftypes.Number, big.NewFloat(2)),
+			tftypes.NewValue(tftypes.String, "2024-01-01T10:00:00Z"),
+		)
+		require.Nil(t, err)
+		var values []tftypes.Value
+		require.NoError(t, result.As(&values))
+		require.Len(t, values, 2)
+		assertString(t, "2024-01-02T09:30:00Z", values[0])
+		assertString(t, "2024-01-03T09:30:00Z", values[1])
+	})
+
+	t.Run("CronNextInvalidExpression", func(t *testing.T) {
+		t.Parallel()
+		_, err := callFunction(t, "cron_next", tftypes.List{ElementType: tftypes.String},
+			tftypes.NewValue(tftypes.String, "not a cron"),
+			tftypes.NewValue(tftypes.Number, big.NewFloat(1)),
+			tftypes.NewValue(tftypes.String, "2024-01-01T10:00:00Z"),
+		)
+		require.NotNil(t, err)
+		assert.Contains(t, err.Text, "is not a valid cron expression")
+	})
+
+	t.Run("CronNextRequiresFrom", func(t *testing.T) {
+		t.Parallel()
+		_, err := callFunction(t, "cron_next", tftypes.List{ElementType: tftypes.String},
+			tftypes.NewValue(tftypes.String, "0 30 9 * * *"),
+			tftypes.NewValue(tftypes.Number, big.NewFloat(1)),
+		)
+		require.NotNil(t, err)
+	})
+
+	t.Run("CronNextInvalidFrom", func(t *testing.T) {
+		t.Parallel()
+		_, err := callFunction(t, "cron_next", tftypes.List{ElementType: tftypes.String},
+			tftypes.NewValue(tftypes.String, "0 30 9 * * *"),
+			tftypes.NewValue(tftypes.Number, big.NewFloat(1)),
+			tftypes.NewValue(tftypes.String, "tomorrow"),
+		)
+		require.NotNil(t, err)
+		assert.Contains(t, err.Text, `"tomorrow" is not an RFC 3339 timestamp`)
+	})
+
+	t.Run("ValidateParameter", func(t *testing.T) {
+		t.Parallel()
+		rules := func(rules map[string]string) tftypes.Value {
+			values := map[string]tftypes.Value{}
+			for k, v := range rules {
+				values[k] = tftypes.NewValue(tftypes.String, v)
+			}
+			return tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, values)
+		}
+		for _, tc := range []struct {
+			Name  string
+			Type  string
+			Value string
+			Rules map[string]string
+			Error string
+		}{{
+			Name:  "ValidNumber",
+			Type:  "number",
+			Value: "5",
+			Rules: map[string]string{"min": "1", "max": "10"},
+		}, {
+			Name:  "NumberAboveMax",
+			Type:  "number",
+			Value: "11",
+			Rules: map[string]string{"min": "1", "max": "10"},
+			Error: "is more than the maximum 10",
+		}, {
+			Name:  "DecimalNotOnStep",
+			Type:  "number",
+			Value: "1.25",
+			Rules: map[string]string{"min": "0.5", "step": "0.5", "precision": "2"},
+			Error: "is not a multiple of the step 0.5 from 0.5",
+		}, {
+			Name:  "ListOfStringsMaxItems",
+			Type:  "list(string)",
+			Value: `["a","b","c"]`,
+			Rules: map[string]string{"max_items": "2"},
+			Error: "more than the maximum 2",
+		}, {
+			Name:  "ListOfStringsMinItems",
+			Type:  "list(string)",
+			Value: `["a"]`,
+			Rules: map[string]string{"min_items": "2", "max_items": "3"},
+			Error: "less than the minimum 2",
+		}, {
+			Name:  "ListOfStringsUniqueItems",
+			Type:  "list(string)",
+			Value: `["a","b","a"]`,
+			Rules: map[string]string{"unique_items": "true"},
+			Error: `contains the item "a" more than once`,
+		}, {
+			Name:  "ListOfStringsItemRegex",
+			Type:  "list(string)",
+			Value: `["main","dev branch"]`,
+			Rules: map[string]string{"regex": "^[a-z-]+$", "error": "invalid branch"},
+			Error: `item "dev branch" does not match`,
+		}, {
+			Name:  "UniqueItemsNotABool",
+			Type:  "list(string)",
+			Value: `["a"]`,
+			Rules: map[string]string{"unique_items": "yes"},
+			Error: `rule "unique_items" must be a bool, got "yes"`,
+		}, {
+			Name:  "ZeroMinItems",
+			Type:  "list(string)",
+			Value: `[]`,
+			Rules: map[string]string{"min_items": "0"},
+			Error: `rule "min_items" must be at least 1, got 0`,
+		}, {
+			Name:  "NegativeMaxLength",
+			Type:  "string",
+			Value: "apple",
+			Rules: map[string]string{"max_length": "-1"},
+			Error: `rule "max_length" must be at least 1, got -1`,
+		}, {
+			Name:  "PrecisionOutOfRange",
+			Type:  "number",
+			Value: "1",
+			Rules: map[string]string{"precision": "16"},
+			Error: `rule "precision" must be between 0 and 15, got 16`,
+		}, {
+			Name:  "StringRegex",
+			Type:  "string",
+			Value: "apple",
+			Rules: map[string]string{"regex": "banana", "error": "bad fruit"},
+			Error: "bad fruit",
+		}, {
+			Name:  "StringMinLength",
+			Type:  "string",
+			Value: "ab",
+			Rules: map[string]string{"min_length": "3", "max_length": "8"},
+			Error: "less than the minimum 3",
+		}, {
+			Name:  "NotAMapOfStrings",
+			Type:  "map(string)",
+			Value: `{"replicas":3}`,
+			Error: "is not an object of strings",
+		}, {
+			Name:  "NotABool",
+			Type:  "bool",
+			Value: "cat",
+			Error: `"cat" is not a bool`,
+		}, {
+			Name:  "UnknownRule",
+			Type:  "string",
+			Value: "apple",
+			Rules: map[string]string{"length": "5"},
+			Error: `unsupported rule "length"`,
+		}} {
+			tc := tc
+			t.Run(tc.Name, func(t *testing.T) {
+				t.Parallel()
+				result, err := callFunction(t, "validate_parameter", tftypes.String,
+					tftypes.NewValue(tftypes.String, tc.Type),
+					tftypes.NewValue(tftypes.String, tc.Value),
+					rules(tc.Rules),
+				)
+				if tc.Error != "" {
+					require.NotNil(t, err)
+					assert.Contains(t, err.Text, tc.Error)
+					return
+				}
+				require.Nil(t, err)
+				assertString(t, tc.Value, result)
+			})
+		}
+	})
+}
+
+// callFunction calls a provider-defined function through the provider server
+// and decodes its result as returnType.
+func callFunction(t *testing.T, name string, returnType tftypes.Type, args ...tftypes.Value) (tftypes.Value, *tfprotov6.FunctionError) {
+	t.Helper()
+	ctx := context.Background()
+
+	serverFactory, err := provider.NewServer(ctx)
+	require.NoError(t, err)
+
+	arguments := make([]*tfprotov6.DynamicValue, 0, len(args))
+	for _, arg := range args {
+		value, err := tfprotov6.NewDynamicValue(arg.Type(), arg)
+		require.NoError(t, err)
+		arguments = append(arguments, &value)
+	}
+
+	resp, err := serverFactory().CallFunction(ctx, &tfprotov6.CallFunctionRequest{
+		Name:      name,
+		Arguments: arguments,
+	})
+	require.NoError(t, err)
+	if resp.Error != nil {
+		return tftypes.Value{}, resp.Error
+	}
+	result, err := resp.Result.Unmarshal(returnType)
+	require.NoError(t, err)
+	return result, nil
+}
+
+func assertString(t *testing.T, expected string, value tftypes.Value) {
+	t.Helper()
+	var got string
+	require.NoError(t, value.As(&got))
+	assert.Equal(t, expected, got)
+}
@@ -2,12 +2,10 @@ package provider
 
 import (
 	"context"
-	"fmt"
+	"reflect"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-
-	"github.com/wirtualdev/terraform-provider-wirtual/provider/helpers"
 )
 
 // gitAuthDataSource returns a schema for a Git authentication data source.
@@ -28,8 +26,11 @@ func gitAuthDataSource() *schema.Resource {
 			}
 			rd.SetId(id)
 
-			accessToken := helpers.OptionalEnv(GitAuthAccessTokenEnvironmentVariable(id))
-			rd.Set("access_token", accessToken)
+			config, valid := i.(config)
+			if !valid {
+				return diag.Errorf("config was unexpected type %q", reflect.TypeOf(i).String())
+			}
+			rd.Set("access_token", config.BuildContext.GitAuth[id])
 
 			return nil
 		},
@@ -49,5 +50,5 @@ func gitAuthDataSource() *schema.Resource {
 }
 
 func GitAuthAccessTokenEnvironmentVariable(id string) string {
-	return fmt.Sprintf("WIRTUAL_GIT_AUTH_ACCESS_TOKEN_%s", id)
+	return gitAuthEnvironmentVariablePrefix + id
 }
@@ -1,25 +1,10 @@
 package helpers
 
 import (
-	"fmt"
 	"os"
+	"strings"
 )
 
-// RequireEnv requires environment variable to be present.
-// The constraint can be verified only during execution of the workspace build
-// (determined with env `WIRTUAL_WORKSPACE_BUILD_ID`).
-func RequireEnv(name string) (string, error) {
-	if os.Getenv("WIRTUAL_WORKSPACE_BUILD_ID") == "" {
-		return os.Getenv(name), nil
-	}
-
-	val := os.Getenv(name)
-	if val == "" {
-		return "", fmt.Errorf("%s is required", name)
-	}
-	return val, nil
-}
-
 // OptionalEnv returns the value for environment variable if it exists,
 // otherwise returns an empty string.
 func OptionalEnv(name string) string {
@@ -35,3 +20,18 @@ func OptionalEnvOrDefault(name, defaultValue string) string {
 	}
 	return val
 }
+
+// PrefixedEnv returns all environment variables starting with prefix, keyed by
+// the remainder of their name. Variables that are set to an empty string are
+// included.
+func PrefixedEnv(prefix string) map[string]string {
+	vars := map[string]string{}
+	for _, kv := range os.Environ() {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		vars[strings.TrimPrefix(name, prefix)] = value
+	}
+	return vars
+}
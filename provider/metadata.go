@@ -20,17 +20,10 @@ func metadataResource() *schema.Resource {
 			"Alternatively, to attach metadata to the agent, use a `metadata` block within a `wirtual_agent` resource.",
 		CreateContext: func(c context.Context, resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
 			resourceData.SetId(uuid.NewString())
-
-			items, err := populateIsNull(resourceData)
-			if err != nil {
-				return errorAsDiagnostics(err)
-			}
-			err = resourceData.Set("item", items)
-			if err != nil {
-				return errorAsDiagnostics(err)
-			}
-
-			return nil
+			return setMetadataItems(resourceData)
+		},
+		UpdateContext: func(c context.Context, resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return setMetadataItems(resourceData)
 		},
 		ReadContext: func(c context.Context, resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
 			return nil
@@ -48,7 +41,6 @@ func metadataResource() *schema.Resource {
 			"hide": {
 				Type:        schema.TypeBool,
 				Description: "Hide the resource from the UI.",
-				ForceNew:    true,
 				Optional:    true,
 			},
 			"icon": {
@@ -56,7 +48,6 @@ func metadataResource() *schema.Resource {
 				Description: "A URL to an icon that will display in the dashboard. View built-in " +
 					"icons [here](https://github.com/wirtualdev/wirtual/tree/main/site/static/icon). Use a " +
 					"built-in icon with `\"${data.wirtual_workspace.me.access_url}/icon/<path>\"`.",
-				ForceNew: true,
 				Optional: true,
 				ValidateFunc: func(i interface{}, s string) ([]string, []error) {
 					_, err := url.Parse(s)
@@ -140,3 +131,18 @@ func metadataResource() *schema.Resource {
 		},
 	}
 }
+
+// setMetadataItems sets the "item" field of a wirtual_metadata resource from
+// the raw plan, so items with null values are marked as such. Items are read by
+// the workspace at build time, so changing them replaces the resource.
+func setMetadataItems(resourceData *schema.ResourceData) diag.Diagnostics {
+	items, err := populateIsNull(resourceData)
+	if err != nil {
+		return errorAsDiagnostics(err)
+	}
+	err = resourceData.Set("item", items)
+	if err != nil {
+		return errorAsDiagnostics(err)
+	}
+	return nil
+}
@@ -5,20 +5,15 @@ import (
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/stretchr/testify/require"
-	"github.com/wirtualdev/terraform-provider-wirtual/provider"
 )
 
 func TestMetadata(t *testing.T) {
 	t.Parallel()
-	prov := provider.New()
 	resource.Test(t, resource.TestCase{
-		Providers: map[string]*schema.Provider{
-			"wirtual": prov,
-		},
-		IsUnitTest: true,
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
 		Steps: []resource.TestStep{{
 			Config: `
 				provider "wirtual" {
@@ -95,12 +90,9 @@ func TestMetadata(t *testing.T) {
 
 func TestMetadataDuplicateKeys(t *testing.T) {
 	t.Parallel()
-	prov := provider.New()
 	resource.Test(t, resource.TestCase{
-		Providers: map[string]*schema.Provider{
-			"wirtual": prov,
-		},
-		IsUnitTest: true,
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
 		Steps: []resource.TestStep{{
 			Config: `
 				provider "wirtual" {
@@ -128,3 +120,63 @@ func TestMetadataDuplicateKeys(t *testing.T) {
 		}},
 	})
 }
+
+func TestMetadataUpdateInPlace(t *testing.T) {
+	t.Parallel()
+
+	config := func(icon, value string) string {
+		return `
+			provider "wirtual" {
+			}
+			resource "wirtual_agent" "dev" {
+				os = "linux"
+				arch = "amd64"
+			}
+			resource "wirtual_metadata" "agent" {
+				resource_id = wirtual_agent.dev.id
+				icon = "` + icon + `"
+				item {
+					key = "foo"
+					value = ` + value + `
+				}
+			}
+		`
+	}
+
+	var id string
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
+		Steps: []resource.TestStep{{
+			Config: config("/icon/storage.svg", "null"),
+			Check: func(state *terraform.State) error {
+				metadata := state.Modules[0].Resources["wirtual_metadata.agent"]
+				require.NotNil(t, metadata)
+				require.Equal(t, "true", metadata.Primary.Attributes["item.0.is_null"])
+				id = metadata.Primary.ID
+				return nil
+			},
+		}, {
+			Config: config("/icon/database.svg", "null"),
+			Check: func(state *terraform.State) error {
+				metadata := state.Modules[0].Resources["wirtual_metadata.agent"]
+				require.NotNil(t, metadata)
+				require.Equal(t, id, metadata.Primary.ID, "metadata was replaced")
+				require.Equal(t, "/icon/database.svg", metadata.Primary.Attributes["icon"])
+				return nil
+			},
+		}, {
+			// Items are read by the workspace at build time, so changing them
+			// replaces the resource.
+			Config: config("/icon/database.svg", `"bar"`),
+			Check: func(state *terraform.State) error {
+				metadata := state.Modules[0].Resources["wirtual_metadata.agent"]
+				require.NotNil(t, metadata)
+				require.NotEqual(t, id, metadata.Primary.ID, "metadata was updated in place")
+				require.Equal(t, "bar", metadata.Primary.Attributes["item.0.value"])
+				require.Equal(t, "false", metadata.Primary.Attributes["item.0.is_null"])
+				return nil
+			},
+		}},
+	})
+}
@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type ownerCredentialsEphemeralResource struct {
+	buildContext *buildContext
+}
+
+type ownerCredentialsModel struct {
+	SessionToken    types.String `tfsdk:"session_token"`
+	OIDCAccessToken types.String `tfsdk:"oidc_access_token"`
+	SSHPrivateKey   types.String `tfsdk:"ssh_private_key"`
+}
+
+var _ ephemeral.EphemeralResourceWithConfigure = &ownerCredentialsEphemeralResource{}
+
+func newOwnerCredentialsEphemeralResource() ephemeral.EphemeralResource {
+	return &ownerCredentialsEphemeralResource{}
+}
+
+func (*ownerCredentialsEphemeralResource) Metadata(_ context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_owner_credentials"
+}
+
+func (*ownerCredentialsEphemeralResource) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Use this ephemeral resource to access the secrets of the workspace owner without storing them in the " +
+			"Terraform plan or state. Requires Terraform 1.10 or later.",
+		Attributes: map[string]schema.Attribute{
+			"session_token": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Session token for authenticating with a Wirtual deployment. It is regenerated every time a workspace is started.",
+			},
+			"oidc_access_token": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "A valid OpenID Connect access token of the workspace owner. This is only available if the workspace owner authenticated with OpenID Connect. If a valid token cannot be obtained, this value will be an empty string.",
+			},
+			"ssh_private_key": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The user's generated SSH private key.",
+			},
+		},
+	}
+}
+
+func (r *ownerCredentialsEphemeralResource) Configure(_ context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	r.buildContext = ephemeralBuildContext(req.ProviderData, &resp.Diagnostics)
+}
+
+func (r *ownerCredentialsEphemeralResource) Open(ctx context.Context, _ ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	if r.buildContext == nil {
+		resp.Diagnostics.AddError("Provider not configured", "The wirtual provider must be configured before opening wirtual_owner_credentials.")
+		return
+	}
+	owner := r.buildContext.Owner
+	resp.Diagnostics.Append(resp.Result.Set(ctx, ownerCredentialsModel{
+		SessionToken:    types.StringValue(owner.SessionToken),
+		OIDCAccessToken: types.StringValue(owner.OIDCAccessToken),
+		SSHPrivateKey:   types.StringValue(owner.SSHPrivateKey),
+	})...)
+}
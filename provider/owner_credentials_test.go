@@ -0,0 +1,95 @@
+package provider_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wirtualdev/terraform-provider-wirtual/provider"
+)
+
+func TestOwnerCredentials(t *testing.T) {
+	t.Parallel()
+
+	buildContextFile := filepath.Join(t.TempDir(), "build.yaml")
+	require.NoError(t, os.WriteFile(buildContextFile, []byte(`
+owner:
+  session_token: session
+  oidc_access_token: oidc
+  ssh_private_key: private
+`), 0o600))
+
+	result := openEphemeralResource(t, buildContextFile, "wirtual_owner_credentials", tftypes.Object{
+		AttributeTypes: map[string]tftypes.Type{
+			"session_token":     tftypes.String,
+			"oidc_access_token": tftypes.String,
+			"ssh_private_key":   tftypes.String,
+		},
+	}, map[string]tftypes.Value{
+		"session_token":     tftypes.NewValue(tftypes.String, nil),
+		"oidc_access_token": tftypes.NewValue(tftypes.String, nil),
+		"ssh_private_key":   tftypes.NewValue(tftypes.String, nil),
+	})
+	assertString(t, "session", result["session_token"])
+	assertString(t, "oidc", result["oidc_access_token"])
+	assertString(t, "private", result["ssh_private_key"])
+}
+
+// openEphemeralResource configures the provider with the given build context
+// file, opens the named ephemeral resource with config and returns the
+// attributes of its result.
+func openEphemeralResource(t *testing.T, buildContextFile, typeName string, typ tftypes.Object, config map[string]tftypes.Value) map[string]tftypes.Value {
+	t.Helper()
+	ctx := context.Background()
+
+	serverFactory, err := provider.NewServer(ctx)
+	require.NoError(t, err)
+	server := serverFactory()
+
+	providerType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"url":                           tftypes.String,
+		"build_context_file":            tftypes.String,
+		"feature_use_managed_variables": tftypes.Bool,
+	}}
+	providerConfig, err := tfprotov6.NewDynamicValue(providerType, tftypes.NewValue(providerType, map[string]tftypes.Value{
+		"url":                           tftypes.NewValue(tftypes.String, "https://example.com"),
+		"build_context_file":            tftypes.NewValue(tftypes.String, buildContextFile),
+		"feature_use_managed_variables": tftypes.NewValue(tftypes.Bool, nil),
+	}))
+	require.NoError(t, err)
+	configureResp, err := server.ConfigureProvider(ctx, &tfprotov6.ConfigureProviderRequest{
+		TerraformVersion: "1.10.0",
+		Config:           &providerConfig,
+	})
+	require.NoError(t, err)
+	requireNoErrorDiagnostics(t, configureResp.Diagnostics)
+
+	resourceConfig, err := tfprotov6.NewDynamicValue(typ, tftypes.NewValue(typ, config))
+	require.NoError(t, err)
+	ephemeralServer, ok := server.(tfprotov6.ProviderServerWithEphemeralResources)
+	require.True(t, ok, "provider server does not support ephemeral resources")
+	openResp, err := ephemeralServer.OpenEphemeralResource(ctx, &tfprotov6.OpenEphemeralResourceRequest{
+		TypeName: typeName,
+		Config:   &resourceConfig,
+	})
+	require.NoError(t, err)
+	requireNoErrorDiagnostics(t, openResp.Diagnostics)
+
+	result, err := openResp.Result.Unmarshal(typ)
+	require.NoError(t, err)
+	var attributes map[string]tftypes.Value
+	require.NoError(t, result.As(&attributes))
+	return attributes
+}
+
+func requireNoErrorDiagnostics(t *testing.T, diags []*tfprotov6.Diagnostic) {
+	t.Helper()
+	for _, d := range diags {
+		require.NotEqual(t, tfprotov6.DiagnosticSeverityError, d.Severity, "%s: %s", d.Summary, d.Detail)
+	}
+}
@@ -5,12 +5,17 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/url"
-	"os"
+	"reflect"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/hashicorp/go-cty/cty"
@@ -18,6 +23,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/mitchellh/mapstructure"
+	"github.com/santhosh-tekuri/jsonschema/v5"
 	"golang.org/x/xerrors"
 )
 
@@ -29,13 +35,27 @@ type Option struct {
 }
 
 type Validation struct {
-	Min         int
+	Min         float64
 	MinDisabled bool `mapstructure:"min_disabled"`
-	Max         int
+	Max         float64
 	MaxDisabled bool `mapstructure:"max_disabled"`
 
+	// Step requires number values to be a multiple of it, counting from Min
+	// if set. Zero disables the check.
+	Step float64
+	// Precision is the maximum number of decimal places of number values, if
+	// set.
+	Precision *int
+
 	Monotonic string
 
+	MinItems    int  `mapstructure:"min_items"`
+	MaxItems    int  `mapstructure:"max_items"`
+	UniqueItems bool `mapstructure:"unique_items"`
+
+	MinLength int `mapstructure:"min_length"`
+	MaxLength int `mapstructure:"max_length"`
+
 	Regex string
 	Error string
 }
@@ -45,12 +65,91 @@ const (
 	ValidationMonotonicDecreasing = "decreasing"
 )
 
+// Sources of the value of a parameter.
+const (
+	// ParameterSourceDefault is the source of parameters that resolve to their
+	// default, because no value was passed for the workspace build.
+	ParameterSourceDefault = "default"
+	// ParameterSourceUser is the source of values that were chosen for the
+	// workspace build.
+	ParameterSourceUser = "user"
+	// ParameterSourcePrevious is the source of values that are unchanged from
+	// the previous workspace build.
+	ParameterSourcePrevious = "previous"
+)
+
+type VisibleWhen struct {
+	Parameter string
+	Values    []string
+	// Value is the resolved value of the parameter, if the condition sets it.
+	// It's read from the raw config, since the value may be empty.
+	Value *string `mapstructure:"-"`
+}
+
+type Styling struct {
+	Placeholder string
+	Disabled    bool
+}
+
+const (
+	ParameterFormTypeInput       = "input"
+	ParameterFormTypeTextarea    = "textarea"
+	ParameterFormTypeRadio       = "radio"
+	ParameterFormTypeDropdown    = "dropdown"
+	ParameterFormTypeSlider      = "slider"
+	ParameterFormTypeCheckbox    = "checkbox"
+	ParameterFormTypeSwitch      = "switch"
+	ParameterFormTypeMultiSelect = "multi-select"
+	ParameterFormTypeTagSelect   = "tag-select"
+)
+
+// parameterFormTypes lists the form types allowed for each parameter type,
+// depending on whether the parameter has options. The first form type is the
+// default.
+var parameterFormTypes = map[string]map[bool][]string{
+	"string": {
+		false: {ParameterFormTypeInput, ParameterFormTypeTextarea},
+		true:  {ParameterFormTypeRadio, ParameterFormTypeDropdown},
+	},
+	"number": {
+		false: {ParameterFormTypeInput, ParameterFormTypeSlider},
+		true:  {ParameterFormTypeRadio, ParameterFormTypeDropdown},
+	},
+	"bool": {
+		false: {ParameterFormTypeCheckbox, ParameterFormTypeSwitch},
+		true:  {ParameterFormTypeRadio, ParameterFormTypeDropdown},
+	},
+	"list(string)": {
+		false: {ParameterFormTypeTagSelect},
+		true:  {ParameterFormTypeRadio, ParameterFormTypeMultiSelect},
+	},
+	"map(string)": {
+		false: {ParameterFormTypeTextarea},
+		true:  {ParameterFormTypeRadio, ParameterFormTypeDropdown},
+	},
+	"object": {
+		false: {ParameterFormTypeTextarea},
+		true:  {ParameterFormTypeRadio, ParameterFormTypeDropdown},
+	},
+}
+
+// parameterPlaceholderFormTypes are the form types that show a placeholder
+// while the parameter is empty.
+var parameterPlaceholderFormTypes = []string{
+	ParameterFormTypeInput,
+	ParameterFormTypeTextarea,
+	ParameterFormTypeDropdown,
+	ParameterFormTypeMultiSelect,
+	ParameterFormTypeTagSelect,
+}
+
 type Parameter struct {
 	Value       string
 	Name        string
 	DisplayName string `mapstructure:"display_name"`
 	Description string
 	Type        string
+	Schema      string
 	Mutable     bool
 	Default     string
 	Icon        string
@@ -59,6 +158,10 @@ type Parameter struct {
 	Optional    bool
 	Order       int
 	Ephemeral   bool
+	Sensitive   bool
+	FormType    string `mapstructure:"form_type"`
+	Styling     []Styling
+	VisibleWhen []VisibleWhen `mapstructure:"visible_when"`
 }
 
 func parameterDataSource() *schema.Resource {
@@ -67,6 +170,11 @@ func parameterDataSource() *schema.Resource {
 
 		Description: "Use this data source to configure editable options for workspaces.",
 		ReadContext: func(ctx context.Context, rd *schema.ResourceData, i interface{}) diag.Diagnostics {
+			config, valid := i.(config)
+			if !valid {
+				return diag.Errorf("config was unexpected type %q", reflect.TypeOf(i).String())
+			}
+
 			rd.SetId(uuid.NewString())
 
 			fixedValidation, err := fixValidationResourceData(rd.GetRawConfig(), rd.Get("validation"))
@@ -86,6 +194,7 @@ func parameterDataSource() *schema.Resource {
 				DisplayName interface{}
 				Description interface{}
 				Type        interface{}
+				Schema      interface{}
 				Mutable     interface{}
 				Default     interface{}
 				Icon        interface{}
@@ -94,12 +203,17 @@ func parameterDataSource() *schema.Resource {
 				Optional    interface{}
 				Order       interface{}
 				Ephemeral   interface{}
+				Sensitive   interface{}
+				FormType    interface{} `mapstructure:"form_type"`
+				Styling     interface{}
+				VisibleWhen interface{} `mapstructure:"visible_when"`
 			}{
 				Value:       rd.Get("value"),
 				Name:        rd.Get("name"),
 				DisplayName: rd.Get("display_name"),
 				Description: rd.Get("description"),
 				Type:        rd.Get("type"),
+				Schema:      rd.Get("schema"),
 				Mutable:     rd.Get("mutable"),
 				Default:     rd.Get("default"),
 				Icon:        rd.Get("icon"),
@@ -113,12 +227,24 @@ func parameterDataSource() *schema.Resource {
 					rd.Set("optional", val)
 					return val
 				}(),
-				Order:     rd.Get("order"),
-				Ephemeral: rd.Get("ephemeral"),
+				Order:       rd.Get("order"),
+				Ephemeral:   rd.Get("ephemeral"),
+				Sensitive:   rd.Get("sensitive"),
+				FormType:    rd.Get("form_type"),
+				Styling:     rd.Get("styling"),
+				VisibleWhen: rd.Get("visible_when"),
 			}, &parameter)
 			if err != nil {
 				return diag.Errorf("decode parameter: %s", err)
 			}
+			if visibleWhen := rd.GetRawConfig().GetAttr("visible_when"); !visibleWhen.IsNull() {
+				for i, condition := range visibleWhen.AsValueSlice() {
+					if conditionValue := condition.GetAttr("value"); !conditionValue.IsNull() {
+						resolved := conditionValue.AsString()
+						parameter.VisibleWhen[i].Value = &resolved
+					}
+				}
+			}
 			var value string
 			if parameter.Default != "" {
 				err := valueIsType(parameter.Type, parameter.Default)
@@ -127,11 +253,45 @@ func parameterDataSource() *schema.Resource {
 				}
 				value = parameter.Default
 			}
-			envValue, ok := os.LookupEnv(ParameterEnvironmentVariable(parameter.Name))
-			if ok {
-				value = envValue
+			valueSchema, err := parameter.compileSchema()
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			if valueSchema != nil && parameter.Default != "" {
+				err = validateSchema(valueSchema, parameter.Default)
+				if err != nil {
+					return diag.Errorf("default value does not match the schema: %s", err)
+				}
+			}
+			visible, err := parameter.visible(config.BuildContext)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			rd.Set("visible", visible)
+			previousValue, hasPreviousValue := config.BuildContext.previousParameter(parameter.Name)
+			source := ParameterSourceDefault
+			// Hidden parameters are not prompted for, so they always resolve
+			// to their default.
+			if buildValue, ok := config.BuildContext.parameter(parameter.Name); ok && visible {
+				value = buildValue
+				source = ParameterSourceUser
+				if hasPreviousValue && buildValue == previousValue {
+					source = ParameterSourcePrevious
+				}
+			}
+			rd.Set("source", source)
+			rd.Set("is_default", value == parameter.Default)
+			if parameter.Sensitive {
+				// The values of sensitive parameters are only exposed through
+				// the sensitive attribute, so they are masked in plans.
+				rd.Set("value", "")
+				rd.Set("sensitive_value", value)
+				rd.Set("previous_value", "")
+			} else {
+				rd.Set("value", value)
+				rd.Set("sensitive_value", "")
+				rd.Set("previous_value", previousValue)
 			}
-			rd.Set("value", value)
 
 			if !parameter.Mutable && parameter.Ephemeral {
 				return diag.Errorf("parameter can't be immutable and ephemeral")
@@ -141,15 +301,69 @@ func parameterDataSource() *schema.Resource {
 				return diag.Errorf("ephemeral parameter requires the default property")
 			}
 
-			if len(parameter.Validation) == 1 {
-				validation := &parameter.Validation[0]
-				err = validation.Valid(parameter.Type, value)
+			if parameter.Sensitive && len(parameter.Option) > 0 {
+				return diag.Errorf("sensitive parameter can't have options, since their values are stored in plain text")
+			}
+
+			if parameter.Sensitive && !parameter.Mutable && parameter.Default != "" {
+				return diag.Errorf("immutable sensitive parameter can't have a default, since it would be shared by every workspace in plain text")
+			}
+
+			formType, err := parameter.formType()
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			rd.Set("form_type", formType)
+
+			if visible {
+				// Every validation block is evaluated, so that all of the rules
+				// the value breaks are reported at once.
+				var diags diag.Diagnostics
+				for i := range parameter.Validation {
+					validation := &parameter.Validation[i]
+					err = validation.validConfig(parameter.Type)
+					if err == nil {
+						err = validation.Valid(parameter.Type, value)
+						if err == nil && hasPreviousValue {
+							err = validation.ValidMonotonicity(parameter.Type, value, previousValue)
+						}
+						if err != nil && parameter.Sensitive {
+							// Errors about the value may quote it, even in a
+							// different form, e.g. numbers are canonicalized,
+							// so they're always replaced for sensitive
+							// parameters.
+							err = validation.sensitiveError(parameter.Type)
+						}
+					}
+					if err != nil {
+						diags = append(diags, diag.Diagnostic{
+							Severity:      diag.Error,
+							Summary:       err.Error(),
+							AttributePath: cty.GetAttrPath("validation").IndexInt(i),
+						})
+					}
+				}
+				if diags.HasError() {
+					return diags
+				}
+			}
+
+			if valueSchema != nil && visible && value != "" {
+				err = validateSchema(valueSchema, value)
+				if err != nil && parameter.Sensitive {
+					return diag.Errorf("the value of the sensitive parameter does not match the schema")
+				}
 				if err != nil {
-					return diag.FromErr(err)
+					return diag.Errorf("value does not match the schema: %s", err)
 				}
 			}
 
 			if len(parameter.Option) > 0 {
+				// Multi-select options are the individual items of the list.
+				optionType := parameter.Type
+				if formType == ParameterFormTypeMultiSelect {
+					optionType = "string"
+				}
 				names := map[string]interface{}{}
 				values := map[string]interface{}{}
 				for _, option := range parameter.Option {
@@ -161,20 +375,47 @@ func parameterDataSource() *schema.Resource {
 					if exists {
 						return diag.Errorf("multiple options cannot have the same value %q", option.Value)
 					}
-					err := valueIsType(parameter.Type, option.Value)
+					err := valueIsType(optionType, option.Value)
 					if err != nil {
 						return err
 					}
+					if valueSchema != nil {
+						err := validateSchema(valueSchema, option.Value)
+						if err != nil {
+							return diag.Errorf("option value %q does not match the schema: %s", option.Value, err)
+						}
+					}
 					values[option.Value] = nil
 					names[option.Name] = nil
 				}
 
-				if parameter.Default != "" {
+				if parameter.Default != "" && formType == ParameterFormTypeMultiSelect {
+					var items []string
+					_ = json.Unmarshal([]byte(parameter.Default), &items)
+					for _, item := range items {
+						if _, itemIsValid := values[item]; !itemIsValid {
+							return diag.Errorf("default value %q must be defined as one of options", item)
+						}
+					}
+				} else if parameter.Default != "" {
 					_, defaultIsValid := values[parameter.Default]
 					if !defaultIsValid {
 						return diag.Errorf("default value %q must be defined as one of options", parameter.Default)
 					}
 				}
+
+				if formType == ParameterFormTypeMultiSelect && visible {
+					var items []string
+					err := json.Unmarshal([]byte(value), &items)
+					if err != nil && value != "" {
+						return diag.Errorf("value %q is not an array of strings", value)
+					}
+					for _, item := range items {
+						if _, itemIsValid := values[item]; !itemIsValid {
+							return diag.Errorf("value %q must be defined as one of options", item)
+						}
+					}
+				}
 			}
 			return nil
 		},
@@ -184,6 +425,30 @@ func parameterDataSource() *schema.Resource {
 				Computed:    true,
 				Description: "The output value of the parameter.",
 			},
+			"sensitive_value": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The output value of a `sensitive` parameter, which is masked in plans and logs. Empty for other parameters.",
+			},
+			"previous_value": {
+				Type:     schema.TypeString,
+				Computed: true,
+				Description: "The value of the parameter in the previous workspace build, passed to the provider in " +
+					"`WIRTUAL_PARAMETER_PREVIOUS_<sha256 of the name>`. Empty on the first build and for `sensitive` parameters.",
+			},
+			"source": {
+				Type:     schema.TypeString,
+				Computed: true,
+				Description: "Where the value of the parameter comes from: `\"default\"` if no value was passed for the " +
+					"workspace build, `\"previous\"` if the value is unchanged from the previous build, or `\"user\"` " +
+					"otherwise.",
+			},
+			"is_default": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the value of the parameter is equal to its `default`, including when it was chosen explicitly.",
+			},
 			"name": {
 				Type:        schema.TypeString,
 				Required:    true,
@@ -203,8 +468,18 @@ func parameterDataSource() *schema.Resource {
 				Type:         schema.TypeString,
 				Default:      "string",
 				Optional:     true,
-				ValidateFunc: validation.StringInSlice([]string{"number", "string", "bool", "list(string)"}, false),
-				Description:  "The type of this parameter. Must be one of: `\"number\"`, `\"string\"`, `\"bool\"`, or `\"list(string)\"`.",
+				ValidateFunc: validation.StringInSlice([]string{"number", "string", "bool", "list(string)", "map(string)", "object"}, false),
+				Description: "The type of this parameter. Must be one of: `\"number\"`, `\"string\"`, `\"bool\"`, " +
+					"`\"list(string)\"`, `\"map(string)\"`, or `\"object\"`. The value of `map(string)` and `object` " +
+					"parameters is JSON, which can be read with `jsondecode()`.",
+			},
+			"schema": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsJSON,
+				Description: "A JSON Schema that the value of an `object` parameter must match, e.g. " +
+					"`jsonencode({ type = \"object\", required = [\"port\"] })`. Required for, and only valid " +
+					"with, the `object` type. References to other documents are not supported.",
 			},
 			"mutable": {
 				Type:        schema.TypeBool,
@@ -278,13 +553,12 @@ func parameterDataSource() *schema.Resource {
 			},
 			"validation": {
 				Type:        schema.TypeList,
-				MaxItems:    1,
 				Optional:    true,
-				Description: "Validate the input of a parameter.",
+				Description: "Validate the input of a parameter. Every `validation` block is evaluated, and each rule the value breaks is reported with the `error` of its block.",
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"min": {
-							Type:        schema.TypeInt,
+							Type:        schema.TypeFloat,
 							Optional:    true,
 							Description: "The minimum of a number parameter.",
 						},
@@ -294,7 +568,7 @@ func parameterDataSource() *schema.Resource {
 							Description: "Helper field to check if min is present",
 						},
 						"max": {
-							Type:        schema.TypeInt,
+							Type:        schema.TypeFloat,
 							Optional:    true,
 							Description: "The maximum of a number parameter.",
 						},
@@ -303,21 +577,61 @@ func parameterDataSource() *schema.Resource {
 							Computed:    true,
 							Description: "Helper field to check if max is present",
 						},
+						"step": {
+							Type:        schema.TypeFloat,
+							Optional:    true,
+							Description: "The increment between valid values of a number parameter, counting from `min` if set, or zero otherwise. Must be positive.",
+						},
+						"precision": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validation.IntBetween(0, 15),
+							Description:  "The maximum number of decimal places of a number parameter. Use `0` to only allow integers.",
+						},
 						"monotonic": {
 							Type:        schema.TypeString,
 							Optional:    true,
-							Description: "Number monotonicity, either increasing or decreasing.",
+							Description: "Number monotonicity, either increasing or decreasing. Values are compared with `previous_value`, so that they can't decrease or increase between workspace builds respectively.",
+						},
+						"min_items": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validation.IntAtLeast(1),
+							Description:  "The minimum number of items of a list(string) parameter.",
+						},
+						"max_items": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validation.IntAtLeast(1),
+							Description:  "The maximum number of items of a list(string) parameter.",
+						},
+						"unique_items": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Whether the items of a list(string) parameter must be unique.",
+						},
+						"min_length": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validation.IntAtLeast(1),
+							Description:  "The minimum number of characters of a string parameter.",
+						},
+						"max_length": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validation.IntAtLeast(1),
+							Description:  "The maximum number of characters of a string parameter.",
 						},
 						"regex": {
-							Type:          schema.TypeString,
-							ConflictsWith: []string{"validation.0.min", "validation.0.max", "validation.0.monotonic"},
-							Description:   "A regex for the input parameter to match against.",
-							Optional:      true,
+							Type:        schema.TypeString,
+							Description: "A regex for the input parameter to match against. For list(string) parameters, every item must match.",
+							Optional:    true,
 						},
 						"error": {
 							Type:        schema.TypeString,
 							Optional:    true,
-							Description: "An error message to display if the value breaks the validation rules. The following placeholders are supported: {max}, {min}, and {value}.",
+							Description: "An error message to display if the value breaks the validation rules. The following placeholders are supported: {max}, {min}, and {value}. For string parameters, {min} and {max} are the `min_length` and `max_length`, and for list(string) parameters the `min_items` and `max_items`.",
 						},
 					},
 				},
@@ -327,6 +641,91 @@ func parameterDataSource() *schema.Resource {
 				Computed:    true,
 				Description: "Whether this value is optional.",
 			},
+			"visible_when": {
+				Type: schema.TypeList,
+				Description: "Each `visible_when` block makes the parameter depend on the value of another " +
+					"parameter of the template. The parameter is only shown when every condition holds. " +
+					"Hidden parameters resolve to their `default` and are not validated.",
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"parameter": {
+							Type:         schema.TypeString,
+							Description:  "The `name` of the parameter the condition depends on.",
+							Required:     true,
+							ValidateFunc: validation.StringIsNotWhiteSpace,
+						},
+						"value": {
+							Type: schema.TypeString,
+							Description: "The resolved value of the other parameter, e.g. `data.wirtual_parameter.use_gpu.value`. " +
+								"It's compared instead of the value passed for the workspace build, so defaults are taken " +
+								"into account. Required if the other parameter may have no value in the workspace build.",
+							Optional: true,
+						},
+						"values": {
+							Type:        schema.TypeList,
+							Description: "The values of the other parameter for which the condition holds.",
+							Required:    true,
+							MinItems:    1,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+					},
+				},
+			},
+			"visible": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the parameter is shown, according to its `visible_when` conditions.",
+			},
+			"form_type": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ValidateFunc: validation.StringInSlice([]string{
+					ParameterFormTypeInput,
+					ParameterFormTypeTextarea,
+					ParameterFormTypeRadio,
+					ParameterFormTypeDropdown,
+					ParameterFormTypeSlider,
+					ParameterFormTypeCheckbox,
+					ParameterFormTypeSwitch,
+					ParameterFormTypeMultiSelect,
+					ParameterFormTypeTagSelect,
+				}, false),
+				Description: "The widget used to input the parameter in the UI. Parameters with options accept " +
+					"`\"radio\"` (default) or `\"dropdown\"`, and `list(string)` parameters with options also " +
+					"accept `\"multi-select\"`, whose options are the individual items of the list. Without " +
+					"options, `string` parameters accept `\"input\"` (default) or `\"textarea\"`, `number` " +
+					"parameters accept `\"input\"` (default) or `\"slider\"`, which requires a validation " +
+					"`min` and `max`, `bool` parameters accept `\"checkbox\"` (default) or `\"switch\"`, " +
+					"`list(string)` parameters accept `\"tag-select\"` (default), and `map(string)` and `object` " +
+					"parameters accept `\"textarea\"` (default).",
+			},
+			"styling": {
+				Type:        schema.TypeList,
+				MaxItems:    1,
+				Optional:    true,
+				Description: "Hints about how to display the parameter in the UI.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"placeholder": {
+							Type: schema.TypeString,
+							Description: "Text shown while the parameter is empty. Only valid for the " +
+								"`\"input\"`, `\"textarea\"`, `\"dropdown\"`, `\"multi-select\"` and " +
+								"`\"tag-select\"` form types.",
+							Optional: true,
+						},
+						"disabled": {
+							Type:        schema.TypeBool,
+							Description: "Show the parameter, but prevent users from changing its value.",
+							Optional:    true,
+							Default:     false,
+						},
+					},
+				},
+			},
 			"order": {
 				Type:        schema.TypeInt,
 				Optional:    true,
@@ -338,10 +737,131 @@ func parameterDataSource() *schema.Resource {
 				Optional:    true,
 				Description: "The value of an ephemeral parameter will not be preserved between consecutive workspace builds.",
 			},
+			"sensitive": {
+				Type:     schema.TypeBool,
+				Default:  false,
+				Optional: true,
+				Description: "Whether the value of the parameter is a secret, such as an API key. The input is masked in the UI, " +
+					"and the value is exposed in `sensitive_value` instead of `value`. Sensitive parameters can't have " +
+					"options, and immutable sensitive parameters can't have a default.",
+			},
 		},
 	}
 }
 
+// visible evaluates the "visible_when" conditions of the parameter against the
+// resolved values they set, or else the parameter values of the workspace
+// build. Parameters without a value in the build would resolve to their
+// default, which is unknown here, so they are rejected.
+func (p Parameter) visible(bc buildContext) (bool, error) {
+	visible := true
+	for _, condition := range p.VisibleWhen {
+		if condition.Parameter == p.Name {
+			return false, xerrors.Errorf("parameter %q cannot depend on its own value", p.Name)
+		}
+		value, ok := bc.parameter(condition.Parameter)
+		if condition.Value != nil {
+			value = *condition.Value
+		} else if !ok {
+			return false, xerrors.Errorf("parameter %q has no value in the workspace build, set the \"value\" of the visible_when condition to its resolved value", condition.Parameter)
+		}
+		if !slices.Contains(condition.Values, value) {
+			visible = false
+		}
+	}
+	return visible, nil
+}
+
+// formType returns the form type of the parameter, or its default form type
+// if none is set, and reports form types that don't suit the parameter.
+func (p Parameter) formType() (string, error) {
+	allowed := parameterFormTypes[p.Type][len(p.Option) > 0]
+	formType := p.FormType
+	if formType == "" && len(allowed) > 0 {
+		formType = allowed[0]
+	}
+	if !slices.Contains(allowed, formType) {
+		if len(p.Option) > 0 {
+			return "", xerrors.Errorf("form type %q cannot be used for a %s parameter with options, must be one of %q", formType, p.Type, allowed)
+		}
+		return "", xerrors.Errorf("form type %q cannot be used for a %s parameter without options, must be one of %q", formType, p.Type, allowed)
+	}
+	if formType == ParameterFormTypeSlider {
+		hasMin, hasMax := false, false
+		for _, v := range p.Validation {
+			hasMin = hasMin || !v.MinDisabled
+			hasMax = hasMax || !v.MaxDisabled
+		}
+		if !hasMin || !hasMax {
+			return "", xerrors.New(`form type "slider" requires a validation min and max`)
+		}
+	}
+	if len(p.Styling) == 1 && p.Styling[0].Placeholder != "" && !slices.Contains(parameterPlaceholderFormTypes, formType) {
+		return "", xerrors.Errorf("a placeholder cannot be specified for form type %q", formType)
+	}
+	return formType, nil
+}
+
+// compileSchema compiles the JSON Schema of an object parameter. It returns nil
+// for other parameter types.
+func (p Parameter) compileSchema() (*jsonschema.Schema, error) {
+	if p.Type != "object" {
+		if p.Schema != "" {
+			return nil, xerrors.Errorf("a schema cannot be specified for a %s type", p.Type)
+		}
+		return nil, nil
+	}
+	if p.Schema == "" {
+		return nil, xerrors.New("an object parameter requires a schema")
+	}
+	compiler := jsonschema.NewCompiler()
+	// Schemas are embedded in the template, so they can't load other
+	// documents from the filesystem or the network.
+	compiler.LoadURL = func(s string) (io.ReadCloser, error) {
+		return nil, xerrors.Errorf("schema references to %q are not supported", s)
+	}
+	err := compiler.AddResource("parameter.json", strings.NewReader(p.Schema))
+	if err != nil {
+		return nil, xerrors.Errorf("parse schema: %w", err)
+	}
+	compiled, err := compiler.Compile("parameter.json")
+	var schemaErr *jsonschema.SchemaError
+	if errors.As(err, &schemaErr) {
+		// Drop the location of the schema, which is a made up file URL.
+		err = schemaErr.Err
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("compile schema: %w", err)
+	}
+	return compiled, nil
+}
+
+// validateSchema checks a JSON value against the schema of an object
+// parameter, and reports the first violation with its location in the value.
+func validateSchema(valueSchema *jsonschema.Schema, value string) error {
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	var doc interface{}
+	err := decoder.Decode(&doc)
+	if err != nil {
+		return xerrors.Errorf("%q is not valid JSON", value)
+	}
+	err = valueSchema.Validate(doc)
+	var validationErr *jsonschema.ValidationError
+	if errors.As(err, &validationErr) {
+		leaf := validationErr
+		for len(leaf.Causes) > 0 {
+			leaf = leaf.Causes[0]
+		}
+		location := leaf.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		return xerrors.Errorf("at %q: %s", location, leaf.Message)
+	}
+	return err
+}
+
 func fixValidationResourceData(rawConfig cty.Value, validation interface{}) (interface{}, error) {
 	// Read validation from raw config
 	rawValidation, ok := rawConfig.AsValueMap()["validation"]
@@ -354,32 +874,36 @@ func fixValidationResourceData(rawConfig cty.Value, validation interface{}) (int
 		return validation, nil // no validation rules, nothing to fix
 	}
 
-	rawValidationRule := rawValidationArr[0].AsValueMap()
-
 	// Load validation from resource data
 	vArr, ok := validation.([]interface{})
 	if !ok {
 		return nil, xerrors.New("validation should be an array")
 	}
 
-	if len(vArr) == 0 {
-		return validation, nil // no validation rules, nothing to fix
+	if len(vArr) != len(rawValidationArr) {
+		return nil, xerrors.Errorf("expected %d validation rules, got %d", len(rawValidationArr), len(vArr))
 	}
 
-	validationRule, ok := vArr[0].(map[string]interface{})
-	if !ok {
-		return nil, xerrors.New("validation rule should be a map")
-	}
+	for i, v := range vArr {
+		validationRule, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, xerrors.New("validation rule should be a map")
+		}
 
-	validationRule["min_disabled"] = rawValidationRule["min"].IsNull()
-	validationRule["max_disabled"] = rawValidationRule["max"].IsNull()
+		rawValidationRule := rawValidationArr[i].AsValueMap()
+		validationRule["min_disabled"] = rawValidationRule["min"].IsNull()
+		validationRule["max_disabled"] = rawValidationRule["max"].IsNull()
+		if rawValidationRule["precision"].IsNull() {
+			validationRule["precision"] = nil
+		}
+	}
 	return vArr, nil
 }
 
 func valueIsType(typ, value string) diag.Diagnostics {
 	switch typ {
 	case "number":
-		_, err := strconv.ParseFloat(value, 64)
+		_, err := parseNumber(value)
 		if err != nil {
 			return diag.Errorf("%q is not a number", value)
 		}
@@ -394,6 +918,16 @@ func valueIsType(typ, value string) diag.Diagnostics {
 		if err != nil {
 			return diag.Errorf("%q is not an array of strings", value)
 		}
+	case "map(string)":
+		var items map[string]string
+		err := json.Unmarshal([]byte(value), &items)
+		if err != nil || items == nil {
+			return diag.Errorf("%q is not an object of strings", value)
+		}
+	case "object":
+		if !json.Valid([]byte(value)) {
+			return diag.Errorf("%q is not valid JSON", value)
+		}
 	case "string":
 		// Anything is a string!
 	default:
@@ -402,7 +936,9 @@ func valueIsType(typ, value string) diag.Diagnostics {
 	return nil
 }
 
-func (v *Validation) Valid(typ, value string) error {
+// validConfig checks the rules of the validation against the type of the
+// parameter, regardless of its value.
+func (v *Validation) validConfig(typ string) error {
 	if typ != "number" {
 		if !v.MinDisabled {
 			return fmt.Errorf("a min cannot be specified for a %s type", typ)
@@ -413,44 +949,112 @@ func (v *Validation) Valid(typ, value string) error {
 		if v.Monotonic != "" {
 			return fmt.Errorf("monotonic validation can only be specified for number types, not %s types", typ)
 		}
+		if v.Step != 0 {
+			return fmt.Errorf("a step cannot be specified for a %s type", typ)
+		}
+		if v.Precision != nil {
+			return fmt.Errorf("a precision cannot be specified for a %s type", typ)
+		}
+	}
+	if typ != "list(string)" {
+		if v.MinItems != 0 {
+			return fmt.Errorf("min_items cannot be specified for a %s type", typ)
+		}
+		if v.MaxItems != 0 {
+			return fmt.Errorf("max_items cannot be specified for a %s type", typ)
+		}
+		if v.UniqueItems {
+			return fmt.Errorf("unique_items cannot be specified for a %s type", typ)
+		}
+	}
+	if typ != "string" {
+		if v.MinLength != 0 {
+			return fmt.Errorf("min_length cannot be specified for a %s type", typ)
+		}
+		if v.MaxLength != 0 {
+			return fmt.Errorf("max_length cannot be specified for a %s type", typ)
+		}
 	}
-	if typ != "string" && v.Regex != "" {
+	if typ != "string" && typ != "list(string)" && v.Regex != "" {
 		return fmt.Errorf("a regex cannot be specified for a %s type", typ)
 	}
 	switch typ {
+	case "string":
+		if v.MinLength != 0 && v.MaxLength != 0 && v.MinLength > v.MaxLength {
+			return fmt.Errorf("min_length %d cannot be greater than max_length %d", v.MinLength, v.MaxLength)
+		}
+	case "number":
+		if v.Step < 0 {
+			return fmt.Errorf("step must be positive, got %s", formatNumber(v.Step))
+		}
+		if v.Monotonic != "" && v.Monotonic != ValidationMonotonicIncreasing && v.Monotonic != ValidationMonotonicDecreasing {
+			return fmt.Errorf("number monotonicity can be either %q or %q", ValidationMonotonicIncreasing, ValidationMonotonicDecreasing)
+		}
+	case "list(string)":
+		if v.MinItems != 0 && v.MaxItems != 0 && v.MinItems > v.MaxItems {
+			return fmt.Errorf("min_items %d cannot be greater than max_items %d", v.MinItems, v.MaxItems)
+		}
+	}
+	if v.Regex != "" {
+		if _, err := v.compileRegex(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Validation) Valid(typ, value string) error {
+	if err := v.validConfig(typ); err != nil {
+		return err
+	}
+	switch typ {
 	case "bool":
 		if value != "true" && value != "false" {
 			return fmt.Errorf(`boolean value can be either "true" or "false"`)
 		}
 		return nil
 	case "string":
+		length := utf8.RuneCountInString(value)
+		if v.MinLength != 0 && length < v.MinLength {
+			return takeFirstError(v.errorRendered(float64(v.MinLength), float64(v.MaxLength), value), fmt.Errorf("value %q has %d characters, less than the minimum %d", value, length, v.MinLength))
+		}
+		if v.MaxLength != 0 && length > v.MaxLength {
+			return takeFirstError(v.errorRendered(float64(v.MinLength), float64(v.MaxLength), value), fmt.Errorf("value %q has %d characters, more than the maximum %d", value, length, v.MaxLength))
+		}
 		if v.Regex == "" {
 			return nil
 		}
-		regex, err := regexp.Compile(v.Regex)
+		regex, err := v.compileRegex()
 		if err != nil {
-			return fmt.Errorf("compile regex %q: %s", regex, err)
-		}
-		if v.Error == "" {
-			return fmt.Errorf("an error must be specified with a regex validation")
+			return err
 		}
 		matched := regex.MatchString(value)
 		if !matched {
 			return fmt.Errorf("%s (value %q does not match %q)", v.Error, value, regex)
 		}
 	case "number":
-		num, err := strconv.Atoi(value)
+		num, err := parseNumber(value)
 		if err != nil {
-			return takeFirstError(v.errorRendered(value), fmt.Errorf("value %q is not a number", value))
+			return takeFirstError(v.errorRendered(v.Min, v.Max, value), fmt.Errorf("value %q is not a number", value))
 		}
 		if !v.MinDisabled && num < v.Min {
-			return takeFirstError(v.errorRendered(value), fmt.Errorf("value %d is less than the minimum %d", num, v.Min))
+			return takeFirstError(v.errorRendered(v.Min, v.Max, value), fmt.Errorf("value %s is less than the minimum %s", formatNumber(num), formatNumber(v.Min)))
 		}
 		if !v.MaxDisabled && num > v.Max {
-			return takeFirstError(v.errorRendered(value), fmt.Errorf("value %d is more than the maximum %d", num, v.Max))
+			return takeFirstError(v.errorRendered(v.Min, v.Max, value), fmt.Errorf("value %s is more than the maximum %s", formatNumber(num), formatNumber(v.Max)))
 		}
-		if v.Monotonic != "" && v.Monotonic != ValidationMonotonicIncreasing && v.Monotonic != ValidationMonotonicDecreasing {
-			return fmt.Errorf("number monotonicity can be either %q or %q", ValidationMonotonicIncreasing, ValidationMonotonicDecreasing)
+		if v.Step > 0 {
+			var start float64
+			if !v.MinDisabled {
+				start = v.Min
+			}
+			steps := (num - start) / v.Step
+			if math.Abs(steps-math.Round(steps)) > 1e-9*math.Max(1, math.Abs(steps)) {
+				return takeFirstError(v.errorRendered(v.Min, v.Max, value), fmt.Errorf("value %s is not a multiple of the step %s from %s", formatNumber(num), formatNumber(v.Step), formatNumber(start)))
+			}
+		}
+		if v.Precision != nil && decimalPlaces(num) > *v.Precision {
+			return takeFirstError(v.errorRendered(v.Min, v.Max, value), fmt.Errorf("value %s has more than %d decimal places", formatNumber(num), *v.Precision))
 		}
 	case "list(string)":
 		var listOfStrings []string
@@ -458,16 +1062,134 @@ func (v *Validation) Valid(typ, value string) error {
 		if err != nil {
 			return fmt.Errorf("value %q is not valid list of strings", value)
 		}
+		if v.MinItems != 0 && len(listOfStrings) < v.MinItems {
+			return takeFirstError(v.errorRendered(float64(v.MinItems), float64(v.MaxItems), value), fmt.Errorf("value %s has %d items, less than the minimum %d", value, len(listOfStrings), v.MinItems))
+		}
+		if v.MaxItems != 0 && len(listOfStrings) > v.MaxItems {
+			return takeFirstError(v.errorRendered(float64(v.MinItems), float64(v.MaxItems), value), fmt.Errorf("value %s has %d items, more than the maximum %d", value, len(listOfStrings), v.MaxItems))
+		}
+		if v.UniqueItems {
+			seen := map[string]bool{}
+			for _, item := range listOfStrings {
+				if seen[item] {
+					return takeFirstError(v.errorRendered(float64(v.MinItems), float64(v.MaxItems), value), fmt.Errorf("value %s contains the item %q more than once", value, item))
+				}
+				seen[item] = true
+			}
+		}
+		if v.Regex != "" {
+			regex, err := v.compileRegex()
+			if err != nil {
+				return err
+			}
+			for _, item := range listOfStrings {
+				if !regex.MatchString(item) {
+					return fmt.Errorf("%s (item %q does not match %q)", v.errorRendered(float64(v.MinItems), float64(v.MaxItems), value), item, regex)
+				}
+			}
+		}
 	}
 	return nil
 }
 
+// sensitiveError returns the error for a value of a sensitive parameter that
+// breaks the validation, which unlike the errors of Valid and ValidMonotonicity
+// doesn't quote the value.
+func (v *Validation) sensitiveError(typ string) error {
+	if v.Error == "" {
+		return xerrors.New("the value of the sensitive parameter is invalid")
+	}
+	minimum, maximum := v.Min, v.Max
+	switch typ {
+	case "string":
+		minimum, maximum = float64(v.MinLength), float64(v.MaxLength)
+	case "list(string)":
+		minimum, maximum = float64(v.MinItems), float64(v.MaxItems)
+	}
+	return v.errorRendered(minimum, maximum, "(sensitive value)")
+}
+
+// ValidMonotonicity checks the value of a number parameter against its value
+// in the previous build. Previous values that are not numbers, e.g. because the
+// parameter type changed, are ignored.
+func (v *Validation) ValidMonotonicity(typ, value, previousValue string) error {
+	if typ != "number" || v.Monotonic == "" {
+		return nil
+	}
+	num, err := parseNumber(value)
+	if err != nil {
+		return fmt.Errorf("value %q is not a number", value)
+	}
+	previous, err := parseNumber(previousValue)
+	if err != nil {
+		return nil
+	}
+	switch v.Monotonic {
+	case ValidationMonotonicIncreasing:
+		if num < previous {
+			return takeFirstError(v.errorRendered(v.Min, v.Max, value), fmt.Errorf("value %s must be equal to or greater than the previous value %s", formatNumber(num), formatNumber(previous)))
+		}
+	case ValidationMonotonicDecreasing:
+		if num > previous {
+			return takeFirstError(v.errorRendered(v.Min, v.Max, value), fmt.Errorf("value %s must be equal to or lower than the previous value %s", formatNumber(num), formatNumber(previous)))
+		}
+	}
+	return nil
+}
+
+// parseNumber parses the value of a number parameter, which may be a decimal.
+func parseNumber(value string) (float64, error) {
+	num, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(num) || math.IsInf(num, 0) {
+		return 0, xerrors.Errorf("%q is not a finite number", value)
+	}
+	return num, nil
+}
+
+// formatNumber formats a number without trailing zeros, so integers are
+// rendered without a decimal point.
+func formatNumber(num float64) string {
+	return strconv.FormatFloat(num, 'f', -1, 64)
+}
+
+// decimalPlaces returns the number of decimal places of a number.
+func decimalPlaces(num float64) int {
+	formatted := formatNumber(num)
+	if i := strings.IndexByte(formatted, '.'); i >= 0 {
+		return len(formatted) - i - 1
+	}
+	return 0
+}
+
+// compileRegex compiles the regex of the validation, which must come with an
+// error message.
+func (v *Validation) compileRegex() (*regexp.Regexp, error) {
+	regex, err := regexp.Compile(v.Regex)
+	if err != nil {
+		return nil, fmt.Errorf("compile regex %q: %s", v.Regex, err)
+	}
+	if v.Error == "" {
+		return nil, fmt.Errorf("an error must be specified with a regex validation")
+	}
+	return regex, nil
+}
+
 // ParameterEnvironmentVariable returns the environment variable to specify for
 // a parameter by it's name. It's hashed because spaces and special characters
 // can be used in parameter names that may not be valid in env vars.
 func ParameterEnvironmentVariable(name string) string {
 	sum := sha256.Sum256([]byte(name))
-	return "WIRTUAL_PARAMETER_" + hex.EncodeToString(sum[:])
+	return parameterEnvironmentVariablePrefix + hex.EncodeToString(sum[:])
+}
+
+// ParameterPreviousEnvironmentVariable returns the environment variable that
+// holds the value of a parameter in the previous workspace build.
+func ParameterPreviousEnvironmentVariable(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return previousParameterEnvironmentVariablePrefix + hex.EncodeToString(sum[:])
 }
 
 func takeFirstError(errs ...error) error {
@@ -479,13 +1201,13 @@ func takeFirstError(errs ...error) error {
 	return xerrors.Errorf("developer error: error message is not provided")
 }
 
-func (v *Validation) errorRendered(value string) error {
+func (v *Validation) errorRendered(minimum, maximum float64, value string) error {
 	if v.Error == "" {
 		return nil
 	}
 	r := strings.NewReplacer(
-		"{min}", fmt.Sprintf("%d", v.Min),
-		"{max}", fmt.Sprintf("%d", v.Max),
+		"{min}", formatNumber(minimum),
+		"{max}", formatNumber(maximum),
 		"{value}", value)
 	return xerrors.Errorf(r.Replace(v.Error))
 }
@@ -1,11 +1,13 @@
 package provider_test
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/stretchr/testify/require"
 	"github.com/wirtualdev/terraform-provider-wirtual/provider"
@@ -68,6 +70,13 @@ func TestParameter(t *testing.T) {
 				"order":                "5",
 				"default":              "us-east1-a",
 				"ephemeral":            "true",
+				"form_type":            "radio",
+				"visible":              "true",
+				"sensitive":            "false",
+				"source":               "default",
+				"is_default":           "true",
+				"value":                "us-east1-a",
+				"sensitive_value":      "",
 			} {
 				require.Equal(t, value, attrs[key])
 			}
@@ -632,6 +641,209 @@ data "wirtual_parameter" "region" {
 			}
 			`,
 		ExpectError: regexp.MustCompile("a min cannot be specified for a bool type"),
+	}, {
+		Name: "FormTypeDefault",
+		Config: `
+			data "wirtual_parameter" "region" {
+				name = "Region"
+				type = "bool"
+				default = "true"
+			}
+			`,
+		Check: func(state *terraform.ResourceState) {
+			require.Equal(t, "checkbox", state.Primary.Attributes["form_type"])
+		},
+	}, {
+		Name: "FormTypeSlider",
+		Config: `
+			data "wirtual_parameter" "region" {
+				name = "Region"
+				type = "number"
+				default = "2"
+				form_type = "slider"
+				validation {
+					min = 1
+					max = 4
+				}
+			}
+			`,
+		Check: func(state *terraform.ResourceState) {
+			require.Equal(t, "slider", state.Primary.Attributes["form_type"])
+		},
+	}, {
+		Name: "FormTypeSliderWithoutMax",
+		Config: `
+			data "wirtual_parameter" "region" {
+				name = "Region"
+				type = "number"
+				default = "2"
+				form_type = "slider"
+				validation {
+					min = 1
+				}
+			}
+			`,
+		ExpectError: regexp.MustCompile(`form type "slider" requires a validation min and max`),
+	}, {
+		Name: "FormTypeSwitchForString",
+		Config: `
+			data "wirtual_parameter" "region" {
+				name = "Region"
+				type = "string"
+				form_type = "switch"
+			}
+			`,
+		ExpectError: regexp.MustCompile(`form type "switch" cannot be used for a string parameter without options`),
+	}, {
+		Name: "FormTypeMultiSelect",
+		Config: `
+			data "wirtual_parameter" "region" {
+				name = "Region"
+				type = "list(string)"
+				form_type = "multi-select"
+				default = jsonencode(["us-east1-a", "us-central1-a"])
+				option {
+					name = "US Central"
+					value = "us-central1-a"
+				}
+				option {
+					name = "US East"
+					value = "us-east1-a"
+				}
+				option {
+					name = "US West"
+					value = "us-west1-a"
+				}
+			}
+			`,
+		Check: func(state *terraform.ResourceState) {
+			require.Equal(t, "multi-select", state.Primary.Attributes["form_type"])
+			require.Equal(t, `["us-east1-a","us-central1-a"]`, state.Primary.Attributes["value"])
+		},
+	}, {
+		Name: "FormTypeMultiSelectInvalidDefault",
+		Config: `
+			data "wirtual_parameter" "region" {
+				name = "Region"
+				type = "list(string)"
+				form_type = "multi-select"
+				default = jsonencode(["us-east1-a", "eu-west1-a"])
+				option {
+					name = "US East"
+					value = "us-east1-a"
+				}
+			}
+			`,
+		ExpectError: regexp.MustCompile(`default value "eu-west1-a" must be defined as one of options`),
+	}, {
+		Name: "FormTypeMultiSelectWithoutOptions",
+		Config: `
+			data "wirtual_parameter" "region" {
+				name = "Region"
+				type = "list(string)"
+				form_type = "multi-select"
+			}
+			`,
+		ExpectError: regexp.MustCompile(`form type "multi-select" cannot be used for a list\(string\) parameter without options`),
+	}, {
+		Name: "Styling",
+		Config: `
+			data "wirtual_parameter" "region" {
+				name = "Region"
+				type = "string"
+				form_type = "textarea"
+				styling {
+					placeholder = "Describe your project"
+					disabled = true
+				}
+			}
+			`,
+		Check: func(state *terraform.ResourceState) {
+			require.Equal(t, "Describe your project", state.Primary.Attributes["styling.0.placeholder"])
+			require.Equal(t, "true", state.Primary.Attributes["styling.0.disabled"])
+		},
+	}, {
+		Name: "StylingPlaceholderForRadio",
+		Config: `
+			data "wirtual_parameter" "region" {
+				name = "Region"
+				type = "string"
+				option {
+					name = "US East"
+					value = "us-east1-a"
+				}
+				styling {
+					placeholder = "Pick a region"
+				}
+			}
+			`,
+		ExpectError: regexp.MustCompile(`a placeholder cannot be specified for form type "radio"`),
+	}, {
+		Name: "DecimalNumberValidation",
+		Config: `
+			data "wirtual_parameter" "region" {
+				name = "Region"
+				type = "number"
+				default = 2.5
+				validation {
+					min = 0.5
+					max = 8
+					step = 0.5
+					precision = 1
+				}
+			}
+			`,
+		Check: func(state *terraform.ResourceState) {
+			for key, expected := range map[string]string{
+				"value":                  "2.5",
+				"validation.0.min":       "0.5",
+				"validation.0.max":       "8",
+				"validation.0.step":      "0.5",
+				"validation.0.precision": "1",
+			} {
+				require.Equal(t, expected, state.Primary.Attributes[key], key)
+			}
+		},
+	}, {
+		Name: "DecimalNumberNotOnStep",
+		Config: `
+			data "wirtual_parameter" "region" {
+				name = "Region"
+				type = "number"
+				default = 2.25
+				validation {
+					min = 0.5
+					step = 0.5
+				}
+			}
+			`,
+		ExpectError: regexp.MustCompile(`value 2.25 is not a multiple of the step 0.5 from 0.5`),
+	}, {
+		Name: "DecimalNumberWithoutPrecision",
+		Config: `
+			data "wirtual_parameter" "region" {
+				name = "Region"
+				type = "number"
+				default = 2.125
+				validation {
+					min = 0
+				}
+			}
+			`,
+	}, {
+		Name: "ListOfStringsValidation",
+		Config: `
+			data "wirtual_parameter" "region" {
+				name = "Region"
+				type = "list(string)"
+				default = jsonencode(["us-east1-a", "us-east1-a"])
+				validation {
+					max_items = 3
+					unique_items = true
+				}
+			}
+			`,
+		ExpectError: regexp.MustCompile(`contains the item "us-east1-a" more than once`),
 	}, {
 		Name: "ImmutableEphemeralError",
 		Config: `
@@ -660,10 +872,8 @@ data "wirtual_parameter" "region" {
 		t.Run(tc.Name, func(t *testing.T) {
 			t.Parallel()
 			resource.Test(t, resource.TestCase{
-				Providers: map[string]*schema.Provider{
-					"wirtual": provider.New(),
-				},
-				IsUnitTest: true,
+				ProtoV6ProviderFactories: wirtualFactory(),
+				IsUnitTest:               true,
 				Steps: []resource.TestStep{{
 					Config:      tc.Config,
 					ExpectError: tc.ExpectError,
@@ -683,6 +893,703 @@ data "wirtual_parameter" "region" {
 	}
 }
 
+func TestParameterMultiSelectValue(t *testing.T) {
+	t.Parallel()
+
+	buildContextFile := filepath.Join(t.TempDir(), "build.yaml")
+	require.NoError(t, os.WriteFile(buildContextFile, []byte(`
+parameters:
+  Region: '["us-east1-a", "eu-west1-a"]'
+`), 0o600))
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
+		Steps: []resource.TestStep{{
+			Config: fmt.Sprintf(`
+			provider "wirtual" {
+				build_context_file = %q
+			}
+			data "wirtual_parameter" "region" {
+				name = "Region"
+				type = "list(string)"
+				form_type = "multi-select"
+				option {
+					name = "US East"
+					value = "us-east1-a"
+				}
+				option {
+					name = "US West"
+					value = "us-west1-a"
+				}
+			}
+			`, buildContextFile),
+			ExpectError: regexp.MustCompile(`value "eu-west1-a" must be defined as one of options`),
+		}},
+	})
+}
+
+func TestParameterMultipleValidations(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		Name        string
+		Value       string
+		ExpectError *regexp.Regexp
+	}{{
+		Name:  "Valid",
+		Value: "dev-box",
+	}, {
+		Name:        "TooShort",
+		Value:       "dev",
+		ExpectError: regexp.MustCompile(`must be at least 5 characters`),
+	}, {
+		Name:        "BadCharacters",
+		Value:       "Dev_Box",
+		ExpectError: regexp.MustCompile(`may only contain lowercase letters and dashes`),
+	}, {
+		Name:        "EveryFailureIsReported",
+		Value:       "D_v",
+		ExpectError: regexp.MustCompile(`(?s)must be at least 5 characters.*may only contain lowercase letters and dashes`),
+	}} {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			buildContextFile := filepath.Join(t.TempDir(), "build.json")
+			buildContext := fmt.Sprintf(`{"parameters": {"hostname": %q}}`, tc.Value)
+			require.NoError(t, os.WriteFile(buildContextFile, []byte(buildContext), 0o600))
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: wirtualFactory(),
+				IsUnitTest:               true,
+				Steps: []resource.TestStep{{
+					Config: fmt.Sprintf(`
+					provider "wirtual" {
+						build_context_file = %q
+					}
+					data "wirtual_parameter" "hostname" {
+						name = "hostname"
+						type = "string"
+						validation {
+							min_length = 5
+							max_length = 63
+							error = "must be at least {min} characters"
+						}
+						validation {
+							regex = "^[a-z-]+$"
+							error = "may only contain lowercase letters and dashes"
+						}
+					}
+					`, buildContextFile),
+					ExpectError: tc.ExpectError,
+					Check: func(state *terraform.State) error {
+						param := state.Modules[0].Resources["data.wirtual_parameter.hostname"]
+						require.NotNil(t, param)
+						require.Equal(t, tc.Value, param.Primary.Attributes["value"])
+						require.Equal(t, "2", param.Primary.Attributes["validation.#"])
+						require.Equal(t, "true", param.Primary.Attributes["validation.1.min_disabled"])
+						return nil
+					},
+				}},
+			})
+		})
+	}
+}
+
+func TestParameterSensitive(t *testing.T) {
+	t.Parallel()
+	// Sensitivity is part of the schema, so only the values of sensitive
+	// parameters are exposed in a sensitive attribute, and the other
+	// parameters can still be used in for_each and outputs.
+	parameterSchema := provider.New().DataSourcesMap["wirtual_parameter"].Schema
+	require.False(t, parameterSchema["value"].Sensitive)
+	require.True(t, parameterSchema["sensitive_value"].Sensitive)
+	const buildContext = `{"parameters": {"license": "secret-license"}, "previous_parameters": {"license": "old-license"}}`
+	for _, tc := range []struct {
+		Name         string
+		BuildContext string
+		Parameter    string
+		ExpectError  *regexp.Regexp
+	}{{
+		Name: "Valid",
+		Parameter: `
+			mutable = true
+			default = "license-default"`,
+	}, {
+		Name: "ConfigurationErrorsAreKept",
+		Parameter: `
+			validation {
+				min = 1
+			}`,
+		ExpectError: regexp.MustCompile(`a min cannot be specified for a string type`),
+	}, {
+		Name: "RegexWithoutErrorIsKept",
+		Parameter: `
+			validation {
+				regex = "^lic-"
+			}`,
+		ExpectError: regexp.MustCompile(`an error must be specified with a regex validation`),
+	}, {
+		Name: "Options",
+		Parameter: `
+			option {
+				name = "Trial"
+				value = "trial-key"
+			}`,
+		ExpectError: regexp.MustCompile(`sensitive parameter can't have options`),
+	}, {
+		Name: "ImmutableDefault",
+		Parameter: `
+			default = "license-default"`,
+		ExpectError: regexp.MustCompile(`immutable sensitive parameter can't have a default`),
+	}, {
+		Name: "ValidationErrorHidesValue",
+		Parameter: `
+			validation {
+				regex = "^lic-"
+				error = "{value} is not a license key"
+			}`,
+		ExpectError: regexp.MustCompile(`\(sensitive value\) is not a license key`),
+	}, {
+		Name: "ValidationErrorWithoutMessage",
+		Parameter: `
+			validation {
+				min_length = 32
+			}`,
+		ExpectError: regexp.MustCompile(`the value of the sensitive parameter is invalid`),
+	}, {
+		// Number errors print the canonical form of the value, e.g. 5.5.
+		Name:         "NonCanonicalNumber",
+		BuildContext: `{"parameters": {"license": "5.50"}}`,
+		Parameter: `
+			type = "number"
+			validation {
+				max = 3
+			}`,
+		ExpectError: regexp.MustCompile(`the value of the sensitive parameter is invalid`),
+	}, {
+		Name:         "NonCanonicalNumberMonotonicity",
+		BuildContext: `{"parameters": {"license": "5.50"}, "previous_parameters": {"license": "10"}}`,
+		Parameter: `
+			type = "number"
+			mutable = true
+			validation {
+				monotonic = "increasing"
+			}`,
+		ExpectError: regexp.MustCompile(`the value of the sensitive parameter is invalid`),
+	}} {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			if tc.BuildContext == "" {
+				tc.BuildContext = buildContext
+			}
+			buildContextFile := filepath.Join(t.TempDir(), "build.json")
+			require.NoError(t, os.WriteFile(buildContextFile, []byte(tc.BuildContext), 0o600))
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: wirtualFactory(),
+				IsUnitTest:               true,
+				Steps: []resource.TestStep{{
+					Config: fmt.Sprintf(`
+					provider "wirtual" {
+						build_context_file = %q
+					}
+					data "wirtual_parameter" "license" {
+						name = "license"
+						sensitive = true
+						%s
+					}
+					`, buildContextFile, tc.Parameter),
+					ExpectError: tc.ExpectError,
+					Check: func(state *terraform.State) error {
+						param := state.Modules[0].Resources["data.wirtual_parameter.license"]
+						require.NotNil(t, param)
+						require.Equal(t, "true", param.Primary.Attributes["sensitive"])
+						require.Equal(t, "", param.Primary.Attributes["value"])
+						require.Equal(t, "", param.Primary.Attributes["previous_value"])
+						require.Equal(t, "secret-license", param.Primary.Attributes["sensitive_value"])
+						return nil
+					},
+				}},
+			})
+		})
+	}
+}
+
+func TestParameterStructuredTypes(t *testing.T) {
+	t.Parallel()
+	const portsSchema = `jsonencode({
+		type = "array"
+		items = {
+			type = "object"
+			properties = {
+				port = { type = "integer", minimum = 1, maximum = 65535 }
+				protocol = { enum = ["tcp", "udp"] }
+			}
+			required = ["port"]
+		}
+	})`
+	for _, tc := range []struct {
+		Name         string
+		BuildContext string
+		Parameter    string
+		ExpectError  *regexp.Regexp
+		Value        string
+		FormType     string
+	}{{
+		Name:         "MapOfStrings",
+		BuildContext: `{}`,
+		Parameter: `
+			type = "map(string)"
+			default = jsonencode({ env = "dev" })`,
+		Value:    `{"env":"dev"}`,
+		FormType: "textarea",
+	}, {
+		Name:         "MapOfNumbers",
+		BuildContext: `{}`,
+		Parameter: `
+			type = "map(string)"
+			default = jsonencode({ replicas = 3 })`,
+		ExpectError: regexp.MustCompile(`is not an object of strings`),
+	}, {
+		Name:         "Object",
+		BuildContext: `{"parameters": {"rules": "[{\"port\": 443, \"protocol\": \"tcp\"}]"}}`,
+		Parameter: `
+			type = "object"
+			schema = ` + portsSchema + `
+			default = jsonencode([{ port = 22 }])`,
+		Value:    `[{"port": 443, "protocol": "tcp"}]`,
+		FormType: "textarea",
+	}, {
+		Name:         "ObjectDefaultDoesNotMatch",
+		BuildContext: `{}`,
+		Parameter: `
+			type = "object"
+			schema = ` + portsSchema + `
+			default = jsonencode([{ port = 0 }])`,
+		ExpectError: regexp.MustCompile(`default value does not match the schema: at "/0/port": must be >= 1`),
+	}, {
+		Name:         "ObjectValueDoesNotMatch",
+		BuildContext: `{"parameters": {"rules": "[{\"protocol\": \"icmp\"}]"}}`,
+		Parameter: `
+			type = "object"
+			schema = ` + portsSchema,
+		ExpectError: regexp.MustCompile(`value does not match the schema: at "/0": missing properties: 'port'`),
+	}, {
+		Name:         "ObjectValueNotJSON",
+		BuildContext: `{"parameters": {"rules": "port 22"}}`,
+		Parameter: `
+			type = "object"
+			schema = ` + portsSchema,
+		ExpectError: regexp.MustCompile(`"port 22" is not valid JSON`),
+	}, {
+		Name:         "ObjectRequiresSchema",
+		BuildContext: `{}`,
+		Parameter: `
+			type = "object"
+			default = jsonencode({})`,
+		ExpectError: regexp.MustCompile(`an object parameter requires a schema`),
+	}, {
+		Name:         "SchemaOnlyForObjects",
+		BuildContext: `{}`,
+		Parameter: `
+			type = "map(string)"
+			schema = jsonencode({ type = "object" })`,
+		ExpectError: regexp.MustCompile(`a schema cannot be specified for a map\(string\) type`),
+	}, {
+		Name:         "SchemaReferencesAreNotLoaded",
+		BuildContext: `{}`,
+		Parameter: `
+			type = "object"
+			schema = jsonencode({ "$ref" = "https://example.com/rules.json" })`,
+		ExpectError: regexp.MustCompile(`schema references to "https://example.com/rules.json" are not supported`),
+	}, {
+		Name:         "InvalidSchema",
+		BuildContext: `{}`,
+		Parameter: `
+			type = "object"
+			schema = jsonencode({ type = "port" })`,
+		ExpectError: regexp.MustCompile(`compile schema`),
+	}} {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			buildContextFile := filepath.Join(t.TempDir(), "build.json")
+			require.NoError(t, os.WriteFile(buildContextFile, []byte(tc.BuildContext), 0o600))
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: wirtualFactory(),
+				IsUnitTest:               true,
+				Steps: []resource.TestStep{{
+					Config: fmt.Sprintf(`
+					provider "wirtual" {
+						build_context_file = %q
+					}
+					data "wirtual_parameter" "rules" {
+						name = "rules"
+						%s
+					}
+					`, buildContextFile, tc.Parameter),
+					ExpectError: tc.ExpectError,
+					Check: func(state *terraform.State) error {
+						param := state.Modules[0].Resources["data.wirtual_parameter.rules"]
+						require.NotNil(t, param)
+						require.Equal(t, tc.Value, param.Primary.Attributes["value"])
+						require.Equal(t, tc.FormType, param.Primary.Attributes["form_type"])
+						return nil
+					},
+				}},
+			})
+		})
+	}
+}
+
+func TestParameterSource(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		Name         string
+		BuildContext string
+		VisibleWhen  string
+		Source       string
+		IsDefault    string
+	}{{
+		Name:         "Default",
+		BuildContext: `{}`,
+		Source:       "default",
+		IsDefault:    "true",
+	}, {
+		Name:         "User",
+		BuildContext: `{"parameters": {"region": "eu-west1"}}`,
+		Source:       "user",
+		IsDefault:    "false",
+	}, {
+		Name:         "UserChoseDefault",
+		BuildContext: `{"parameters": {"region": "us-east1"}}`,
+		Source:       "user",
+		IsDefault:    "true",
+	}, {
+		Name:         "UserChangedPrevious",
+		BuildContext: `{"parameters": {"region": "eu-west1"}, "previous_parameters": {"region": "us-central1"}}`,
+		Source:       "user",
+		IsDefault:    "false",
+	}, {
+		Name:         "Previous",
+		BuildContext: `{"parameters": {"region": "eu-west1"}, "previous_parameters": {"region": "eu-west1"}}`,
+		Source:       "previous",
+		IsDefault:    "false",
+	}, {
+		Name:         "Hidden",
+		BuildContext: `{"parameters": {"region": "eu-west1", "cloud": "aws"}}`,
+		VisibleWhen: `
+			visible_when {
+				parameter = "cloud"
+				values = ["gcp"]
+			}`,
+		Source:    "default",
+		IsDefault: "true",
+	}} {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			buildContextFile := filepath.Join(t.TempDir(), "build.json")
+			require.NoError(t, os.WriteFile(buildContextFile, []byte(tc.BuildContext), 0o600))
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: wirtualFactory(),
+				IsUnitTest:               true,
+				Steps: []resource.TestStep{{
+					Config: fmt.Sprintf(`
+					provider "wirtual" {
+						build_context_file = %q
+					}
+					data "wirtual_parameter" "region" {
+						name = "region"
+						default = "us-east1"
+						mutable = true
+						%s
+					}
+					`, buildContextFile, tc.VisibleWhen),
+					Check: func(state *terraform.State) error {
+						param := state.Modules[0].Resources["data.wirtual_parameter.region"]
+						require.NotNil(t, param)
+						require.Equal(t, tc.Source, param.Primary.Attributes["source"])
+						require.Equal(t, tc.IsDefault, param.Primary.Attributes["is_default"])
+						return nil
+					},
+				}},
+			})
+		})
+	}
+}
+
+func TestParameterMonotonicity(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		Name          string
+		Monotonic     string
+		Value         string
+		PreviousValue string
+		ExpectError   *regexp.Regexp
+	}{{
+		Name:          "Increasing",
+		Monotonic:     "increasing",
+		Value:         "15",
+		PreviousValue: "10",
+	}, {
+		Name:          "IncreasingEqual",
+		Monotonic:     "increasing",
+		Value:         "10",
+		PreviousValue: "10",
+	}, {
+		Name:          "IncreasingShrinks",
+		Monotonic:     "increasing",
+		Value:         "5",
+		PreviousValue: "10",
+		ExpectError:   regexp.MustCompile(`value 5 must be equal to or greater than the previous value 10`),
+	}, {
+		Name:          "Decreasing",
+		Monotonic:     "decreasing",
+		Value:         "5",
+		PreviousValue: "10.5",
+	}, {
+		Name:          "DecreasingGrows",
+		Monotonic:     "decreasing",
+		Value:         "10.75",
+		PreviousValue: "10.5",
+		ExpectError:   regexp.MustCompile(`value 10.75 must be equal to or lower than the previous value 10.5`),
+	}, {
+		Name:          "PreviousNotANumber",
+		Monotonic:     "increasing",
+		Value:         "5",
+		PreviousValue: "large",
+	}} {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			buildContextFile := filepath.Join(t.TempDir(), "build.json")
+			buildContext := fmt.Sprintf(`{"parameters": {"Disk Size": %q}, "previous_parameters": {"Disk Size": %q}}`, tc.Value, tc.PreviousValue)
+			require.NoError(t, os.WriteFile(buildContextFile, []byte(buildContext), 0o600))
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: wirtualFactory(),
+				IsUnitTest:               true,
+				Steps: []resource.TestStep{{
+					Config: fmt.Sprintf(`
+					provider "wirtual" {
+						build_context_file = %q
+					}
+					data "wirtual_parameter" "disk_size" {
+						name = "Disk Size"
+						type = "number"
+						default = 1
+						mutable = true
+						validation {
+							monotonic = %q
+						}
+					}
+					`, buildContextFile, tc.Monotonic),
+					ExpectError: tc.ExpectError,
+					Check: func(state *terraform.State) error {
+						param := state.Modules[0].Resources["data.wirtual_parameter.disk_size"]
+						require.NotNil(t, param)
+						require.Equal(t, tc.Value, param.Primary.Attributes["value"])
+						require.Equal(t, tc.PreviousValue, param.Primary.Attributes["previous_value"])
+						return nil
+					},
+				}},
+			})
+		})
+	}
+}
+
+func TestParameterPreviousValueFromEnv(t *testing.T) {
+	t.Setenv(provider.ParameterEnvironmentVariable("Disk Size"), "5")
+	t.Setenv(provider.ParameterPreviousEnvironmentVariable("Disk Size"), "10")
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
+		Steps: []resource.TestStep{{
+			Config: `
+			provider "wirtual" {}
+			data "wirtual_parameter" "disk_size" {
+				name = "Disk Size"
+				type = "number"
+				default = 1
+				mutable = true
+				validation {
+					monotonic = "increasing"
+				}
+			}
+			`,
+			ExpectError: regexp.MustCompile(`value 5 must be equal to or greater than the previous value 10`),
+		}},
+	})
+}
+
+func TestParameterVisibleWhen(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		Name         string
+		BuildContext string
+		Dependency   string
+		Parameter    string
+		ExpectError  *regexp.Regexp
+		Visible      string
+		Value        string
+	}{{
+		Name:         "Visible",
+		BuildContext: `{"parameters": {"use_gpu": "true", "gpu_count": "2"}}`,
+		Parameter: `
+			default = 1
+			visible_when {
+				parameter = "use_gpu"
+				values = ["true"]
+			}`,
+		Visible: "true",
+		Value:   "2",
+	}, {
+		Name:         "Hidden",
+		BuildContext: `{"parameters": {"use_gpu": "false", "gpu_count": "2"}}`,
+		Parameter: `
+			default = 1
+			visible_when {
+				parameter = "use_gpu"
+				values = ["true"]
+			}`,
+		Visible: "false",
+		Value:   "1",
+	}, {
+		Name:         "DependencyWithoutValue",
+		BuildContext: `{"parameters": {"gpu_count": "2"}}`,
+		Parameter: `
+			default = 1
+			visible_when {
+				parameter = "use_gpu"
+				values = ["true"]
+			}`,
+		ExpectError: regexp.MustCompile(`parameter "use_gpu" has no value in the workspace build`),
+	}, {
+		Name:         "DefaultedDependency",
+		BuildContext: `{"parameters": {"gpu_count": "2"}}`,
+		Dependency: `
+			data "wirtual_parameter" "use_gpu" {
+				name = "use_gpu"
+				type = "bool"
+				default = "true"
+			}`,
+		Parameter: `
+			default = 1
+			visible_when {
+				parameter = data.wirtual_parameter.use_gpu.name
+				value = data.wirtual_parameter.use_gpu.value
+				values = ["true"]
+			}`,
+		Visible: "true",
+		Value:   "2",
+	}, {
+		Name:         "HiddenDependency",
+		BuildContext: `{"parameters": {"use_gpu": "true", "gpu_count": "2"}}`,
+		Dependency: `
+			data "wirtual_parameter" "use_gpu" {
+				name = "use_gpu"
+				type = "bool"
+				default = "false"
+				visible_when {
+					parameter = "cloud"
+					value = "aws"
+					values = ["gcp"]
+				}
+			}`,
+		Parameter: `
+			default = 1
+			visible_when {
+				parameter = data.wirtual_parameter.use_gpu.name
+				value = data.wirtual_parameter.use_gpu.value
+				values = ["true"]
+			}`,
+		Visible: "false",
+		Value:   "1",
+	}, {
+		Name:         "AllConditionsMustHold",
+		BuildContext: `{"parameters": {"use_gpu": "true", "region": "eu-west1", "gpu_count": "2"}}`,
+		Parameter: `
+			default = 1
+			visible_when {
+				parameter = "use_gpu"
+				values = ["true"]
+			}
+			visible_when {
+				parameter = "region"
+				values = ["us-east1", "us-central1"]
+			}`,
+		Visible: "false",
+		Value:   "1",
+	}, {
+		Name:         "HiddenSkipsValidation",
+		BuildContext: `{"parameters": {"use_gpu": "false", "gpu_count": "0"}}`,
+		Parameter: `
+			visible_when {
+				parameter = "use_gpu"
+				values = ["true"]
+			}
+			validation {
+				min = 1
+				max = 8
+			}`,
+		Visible: "false",
+		Value:   "",
+	}, {
+		Name:         "VisibleValidates",
+		BuildContext: `{"parameters": {"use_gpu": "true", "gpu_count": "0"}}`,
+		Parameter: `
+			visible_when {
+				parameter = "use_gpu"
+				values = ["true"]
+			}
+			validation {
+				min = 1
+				max = 8
+			}`,
+		ExpectError: regexp.MustCompile(`value 0 is less than the minimum 1`),
+	}, {
+		Name:         "SelfReference",
+		BuildContext: `{}`,
+		Parameter: `
+			default = 1
+			visible_when {
+				parameter = "gpu_count"
+				values = ["1"]
+			}`,
+		ExpectError: regexp.MustCompile(`parameter "gpu_count" cannot depend on its own value`),
+	}} {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			buildContextFile := filepath.Join(t.TempDir(), "build.json")
+			require.NoError(t, os.WriteFile(buildContextFile, []byte(tc.BuildContext), 0o600))
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: wirtualFactory(),
+				IsUnitTest:               true,
+				Steps: []resource.TestStep{{
+					Config: fmt.Sprintf(`
+					provider "wirtual" {
+						build_context_file = %q
+					}
+					%s
+					data "wirtual_parameter" "gpu_count" {
+						name = "gpu_count"
+						type = "number"
+						%s
+					}
+					`, buildContextFile, tc.Dependency, tc.Parameter),
+					ExpectError: tc.ExpectError,
+					Check: func(state *terraform.State) error {
+						param := state.Modules[0].Resources["data.wirtual_parameter.gpu_count"]
+						require.NotNil(t, param)
+						require.Equal(t, tc.Visible, param.Primary.Attributes["visible"])
+						require.Equal(t, tc.Value, param.Primary.Attributes["value"])
+						return nil
+					},
+				}},
+			})
+		})
+	}
+}
+
 func TestValueValidatesType(t *testing.T) {
 	t.Parallel()
 	for _, tc := range []struct {
@@ -692,9 +1599,14 @@ func TestValueValidatesType(t *testing.T) {
 		Regex,
 		RegexError string
 		Min,
-		Max int
+		Max,
+		Step float64
+		Precision                *int
 		MinDisabled, MaxDisabled bool
 		Monotonic                string
+		MinItems, MaxItems       int
+		UniqueItems              bool
+		MinLength, MaxLength     int
 		Error                    *regexp.Regexp
 	}{{
 		Name:        "StringWithMin",
@@ -795,6 +1707,204 @@ func TestValueValidatesType(t *testing.T) {
 		Value:       `[]`,
 		MinDisabled: true,
 		MaxDisabled: true,
+	}, {
+		Name:        "ListOfStringsWithinItemLimits",
+		Type:        "list(string)",
+		Value:       `["first","second"]`,
+		MinDisabled: true,
+		MaxDisabled: true,
+		MinItems:    1,
+		MaxItems:    2,
+		UniqueItems: true,
+		Regex:       "^[a-z]+$",
+		RegexError:  "lowercase only",
+	}, {
+		Name:        "ListOfStringsBelowMinItems",
+		Type:        "list(string)",
+		Value:       `["first"]`,
+		MinDisabled: true,
+		MaxDisabled: true,
+		MinItems:    2,
+		Error:       regexp.MustCompile(`has 1 items, less than the minimum 2`),
+	}, {
+		Name:        "ListOfStringsAboveMaxItems",
+		Type:        "list(string)",
+		Value:       `["first","second","third"]`,
+		MinDisabled: true,
+		MaxDisabled: true,
+		MaxItems:    2,
+		Error:       regexp.MustCompile(`has 3 items, more than the maximum 2`),
+	}, {
+		Name:        "ListOfStringsItemLimitsCustomError",
+		Type:        "list(string)",
+		Value:       `["first","second","third"]`,
+		MinDisabled: true,
+		MaxDisabled: true,
+		MinItems:    1,
+		MaxItems:    2,
+		RegexError:  "pick {min} to {max} items, not {value}",
+		Error:       regexp.MustCompile(`pick 1 to 2 items, not \["first","second","third"\]`),
+	}, {
+		Name:        "ListOfStringsMinItemsAboveMaxItems",
+		Type:        "list(string)",
+		Value:       `["first"]`,
+		MinDisabled: true,
+		MaxDisabled: true,
+		MinItems:    3,
+		MaxItems:    2,
+		Error:       regexp.MustCompile(`min_items 3 cannot be greater than max_items 2`),
+	}, {
+		Name:        "ListOfStringsNotUnique",
+		Type:        "list(string)",
+		Value:       `["first","second","first"]`,
+		MinDisabled: true,
+		MaxDisabled: true,
+		UniqueItems: true,
+		Error:       regexp.MustCompile(`contains the item "first" more than once`),
+	}, {
+		Name:        "ListOfStringsItemDoesNotMatchRegex",
+		Type:        "list(string)",
+		Value:       `["first","Second"]`,
+		MinDisabled: true,
+		MaxDisabled: true,
+		Regex:       "^[a-z]+$",
+		RegexError:  "lowercase only",
+		Error:       regexp.MustCompile(`lowercase only \(item "Second" does not match`),
+	}, {
+		Name:        "ListOfStringsRegexMissingError",
+		Type:        "list(string)",
+		Value:       `["first"]`,
+		MinDisabled: true,
+		MaxDisabled: true,
+		Regex:       "^[a-z]+$",
+		Error:       regexp.MustCompile(`an error must be specified with a regex validation`),
+	}, {
+		Name:      "DecimalNumber",
+		Type:      "number",
+		Value:     "2.5",
+		Min:       0.5,
+		Max:       10,
+		Step:      0.5,
+		Precision: ptr(1),
+	}, {
+		Name:        "DecimalBelowMin",
+		Type:        "number",
+		Value:       "0.25",
+		Min:         0.5,
+		MaxDisabled: true,
+		Error:       regexp.MustCompile(`value 0.25 is less than the minimum 0.5`),
+	}, {
+		Name:       "DecimalAboveMaxCustomError",
+		Type:       "number",
+		Value:      "10.5",
+		Min:        0.5,
+		Max:        10,
+		RegexError: "{value} is not between {min} and {max}",
+		Error:      regexp.MustCompile(`^10.5 is not between 0.5 and 10$`),
+	}, {
+		Name:        "NumberNotOnStep",
+		Type:        "number",
+		Value:       "1.2",
+		Min:         0.5,
+		MaxDisabled: true,
+		Step:        0.5,
+		Error:       regexp.MustCompile(`value 1.2 is not a multiple of the step 0.5 from 0.5`),
+	}, {
+		Name:        "NumberOnStepWithoutMin",
+		Type:        "number",
+		Value:       "0.3",
+		MinDisabled: true,
+		MaxDisabled: true,
+		Step:        0.1,
+	}, {
+		Name:        "NegativeStep",
+		Type:        "number",
+		Value:       "1",
+		MinDisabled: true,
+		MaxDisabled: true,
+		Step:        -1,
+		Error:       regexp.MustCompile(`step must be positive, got -1`),
+	}, {
+		Name:        "NumberTooPrecise",
+		Type:        "number",
+		Value:       "1.125",
+		MinDisabled: true,
+		MaxDisabled: true,
+		Precision:   ptr(2),
+		Error:       regexp.MustCompile(`value 1.125 has more than 2 decimal places`),
+	}, {
+		Name:        "IntegerPrecision",
+		Type:        "number",
+		Value:       "1.5",
+		MinDisabled: true,
+		MaxDisabled: true,
+		Precision:   ptr(0),
+		Error:       regexp.MustCompile(`value 1.5 has more than 0 decimal places`),
+	}, {
+		Name:        "NumberNaN",
+		Type:        "number",
+		Value:       "NaN",
+		MinDisabled: true,
+		MaxDisabled: true,
+		Error:       regexp.MustCompile(`value "NaN" is not a number`),
+	}, {
+		Name:        "StringWithStep",
+		Type:        "string",
+		Value:       "first",
+		MinDisabled: true,
+		MaxDisabled: true,
+		Step:        1,
+		Error:       regexp.MustCompile(`a step cannot be specified for a string type`),
+	}, {
+		Name:        "StringWithMinItems",
+		Type:        "string",
+		Value:       "first",
+		MinDisabled: true,
+		MaxDisabled: true,
+		MinItems:    1,
+		Error:       regexp.MustCompile(`min_items cannot be specified for a string type`),
+	}, {
+		Name:        "StringTooShort",
+		Type:        "string",
+		Value:       "ab",
+		MinDisabled: true,
+		MaxDisabled: true,
+		MinLength:   3,
+		Error:       regexp.MustCompile(`value "ab" has 2 characters, less than the minimum 3`),
+	}, {
+		Name:        "StringTooLong",
+		Type:        "string",
+		Value:       "abcdef",
+		MinDisabled: true,
+		MaxDisabled: true,
+		MinLength:   2,
+		MaxLength:   5,
+		RegexError:  "must be {min} to {max} characters, got {value}",
+		Error:       regexp.MustCompile(`must be 2 to 5 characters, got abcdef`),
+	}, {
+		Name:        "StringLengthCountsCharacters",
+		Type:        "string",
+		Value:       "żółw",
+		MinDisabled: true,
+		MaxDisabled: true,
+		MaxLength:   4,
+	}, {
+		Name:        "MinLengthGreaterThanMaxLength",
+		Type:        "string",
+		Value:       "abc",
+		MinDisabled: true,
+		MaxDisabled: true,
+		MinLength:   5,
+		MaxLength:   2,
+		Error:       regexp.MustCompile(`min_length 5 cannot be greater than max_length 2`),
+	}, {
+		Name:        "NumberWithMaxLength",
+		Type:        "number",
+		Value:       "1",
+		MinDisabled: true,
+		MaxDisabled: true,
+		MaxLength:   2,
+		Error:       regexp.MustCompile(`max_length cannot be specified for a number type`),
 	}} {
 		tc := tc
 		t.Run(tc.Name, func(t *testing.T) {
@@ -804,7 +1914,14 @@ func TestValueValidatesType(t *testing.T) {
 				MinDisabled: tc.MinDisabled,
 				Max:         tc.Max,
 				MaxDisabled: tc.MaxDisabled,
+				Step:        tc.Step,
+				Precision:   tc.Precision,
 				Monotonic:   tc.Monotonic,
+				MinItems:    tc.MinItems,
+				MaxItems:    tc.MaxItems,
+				UniqueItems: tc.UniqueItems,
+				MinLength:   tc.MinLength,
+				MaxLength:   tc.MaxLength,
 				Regex:       tc.Regex,
 				Error:       tc.RegexError,
 			}
@@ -818,3 +1935,7 @@ func TestValueValidatesType(t *testing.T) {
 		})
 	}
 }
+
+func ptr[T any](v T) *T {
+	return &v
+}
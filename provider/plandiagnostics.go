@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"golang.org/x/xerrors"
+)
+
+// planDiagnosticsKey is the context key of the diagnostics reported while
+// planning a resource.
+type planDiagnosticsKey struct{}
+
+// planDiagnostics collects the diagnostics reported by CustomizeDiff funcs,
+// which can only return a single error.
+type planDiagnostics struct {
+	mu    sync.Mutex
+	diags diag.Diagnostics
+}
+
+// errPlanDiagnostics is returned by CustomizeDiff funcs to fail the plan after
+// reporting their errors with planErrors. The server returned by NewServer
+// replaces it with the reported errors.
+var errPlanDiagnostics = xerrors.New("invalid resource configuration")
+
+// addPlanWarnings reports warnings from a CustomizeDiff func, to be returned
+// with the plan of the resource. CustomizeDiff may run more than once per
+// plan, so identical warnings are only reported once. Outside of the server
+// returned by NewServer, the warnings are dropped.
+func addPlanWarnings(ctx context.Context, diags ...diag.Diagnostic) {
+	collected, ok := ctx.Value(planDiagnosticsKey{}).(*planDiagnostics)
+	if !ok {
+		return
+	}
+	collected.mu.Lock()
+	defer collected.mu.Unlock()
+	for _, d := range diags {
+		duplicate := false
+		for _, other := range collected.diags {
+			if d.Severity == other.Severity && d.Summary == other.Summary && d.Detail == other.Detail && d.AttributePath.Equals(other.AttributePath) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			collected.diags = append(collected.diags, d)
+		}
+	}
+}
+
+// planErrors reports the errors and warnings of a CustomizeDiff func, and
+// returns the error to fail the plan with if there are errors. The SDK only
+// reports a single error from CustomizeDiff, so outside of the server
+// returned by NewServer, the first error is returned instead.
+func planErrors(ctx context.Context, diags diag.Diagnostics) error {
+	if !diags.HasError() {
+		addPlanWarnings(ctx, diags...)
+		return nil
+	}
+	if _, ok := ctx.Value(planDiagnosticsKey{}).(*planDiagnostics); !ok {
+		for _, d := range diags {
+			if d.Severity == diag.Error {
+				return d.AttributePath.NewErrorf("%s", d.Summary)
+			}
+		}
+	}
+	addPlanWarnings(ctx, diags...)
+	return errPlanDiagnostics
+}
+
+// planDiagnosticServer wraps the SDKv2 provider server to return the
+// diagnostics reported with addPlanWarnings and planErrors in the response to
+// PlanResourceChange.
+type planDiagnosticServer struct {
+	tfprotov6.ProviderServer
+}
+
+func (s planDiagnosticServer) PlanResourceChange(ctx context.Context, req *tfprotov6.PlanResourceChangeRequest) (*tfprotov6.PlanResourceChangeResponse, error) {
+	collected := &planDiagnostics{}
+	resp, err := s.ProviderServer.PlanResourceChange(context.WithValue(ctx, planDiagnosticsKey{}, collected), req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	collected.mu.Lock()
+	defer collected.mu.Unlock()
+	if collected.diags.HasError() {
+		diagnostics := resp.Diagnostics[:0]
+		for _, d := range resp.Diagnostics {
+			if d.Severity == tfprotov6.DiagnosticSeverityError && d.Summary == errPlanDiagnostics.Error() {
+				continue
+			}
+			diagnostics = append(diagnostics, d)
+		}
+		resp.Diagnostics = diagnostics
+	}
+	for _, d := range collected.diags {
+		severity := tfprotov6.DiagnosticSeverityWarning
+		if d.Severity == diag.Error {
+			severity = tfprotov6.DiagnosticSeverityError
+		}
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity:  severity,
+			Summary:   d.Summary,
+			Detail:    d.Detail,
+			Attribute: attributePath(d.AttributePath),
+		})
+	}
+	return resp, nil
+}
+
+// attributePath converts the path of a diagnostic to its protocol form.
+func attributePath(path cty.Path) *tftypes.AttributePath {
+	if len(path) == 0 {
+		return nil
+	}
+	attributePath := tftypes.NewAttributePath()
+	for _, step := range path {
+		switch step := step.(type) {
+		case cty.GetAttrStep:
+			attributePath = attributePath.WithAttributeName(step.Name)
+		case cty.IndexStep:
+			switch step.Key.Type() {
+			case cty.Number:
+				i, _ := step.Key.AsBigFloat().Int64()
+				attributePath = attributePath.WithElementKeyInt(int(i))
+			case cty.String:
+				attributePath = attributePath.WithElementKeyString(step.Key.AsString())
+			}
+		}
+	}
+	return attributePath
+}
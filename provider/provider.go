@@ -13,7 +13,8 @@ import (
 )
 
 type config struct {
-	URL *url.URL
+	URL          *url.URL
+	BuildContext buildContext
 }
 
 // New returns a new Terraform provider.
@@ -35,6 +36,15 @@ func New() *schema.Provider {
 					return nil, nil
 				},
 			},
+			"build_context_file": {
+				Type: schema.TypeString,
+				Description: "Path to a JSON or YAML file describing the workspace build: the workspace, its owner " +
+					"and template, parameter values keyed by name, external auth tokens and agent init scripts. " +
+					"Useful for exercising a template outside of Wirtual. `WIRTUAL_*` environment variables " +
+					"take precedence over values in this file.",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("WIRTUAL_BUILD_CONTEXT_FILE", ""),
+			},
 			"feature_use_managed_variables": {
 				Type:        schema.TypeBool,
 				Description: "Feature: use managed Terraform variables. The feature flag is not used anymore as Terraform variables are now exclusively utilized for template-wide variables.",
@@ -63,9 +73,15 @@ func New() *schema.Provider {
 				}
 				parsed.Host = rawHost
 			}
+			buildContextFile, _ := resourceData.Get("build_context_file").(string)
+			buildContext, diags := loadBuildContext(buildContextFile)
+			if diags.HasError() {
+				return nil, diags
+			}
 			return config{
-				URL: parsed,
-			}, nil
+				URL:          parsed,
+				BuildContext: buildContext,
+			}, diags
 		},
 		DataSourcesMap: map[string]*schema.Resource{
 			"wirtual_workspace":       workspaceDataSource(),
@@ -80,9 +96,11 @@ func New() *schema.Provider {
 			"wirtual_agent":          agentResource(),
 			"wirtual_agent_instance": agentInstanceResource(),
 			"wirtual_app":            appResource(),
+			"wirtual_app_group":      appGroupResource(),
 			"wirtual_metadata":       metadataResource(),
 			"wirtual_script":         scriptResource(),
 			"wirtual_env":            envResource(),
+			"wirtual_devcontainer":   devcontainerResource(),
 		},
 	}
 }
@@ -1,16 +1,37 @@
 package provider_test
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/wirtualdev/terraform-provider-wirtual/provider"
 )
 
+// wirtualFactory returns the provider factories for acceptance tests, serving
+// the provider the same way main does.
+func wirtualFactory() map[string]func() (tfprotov6.ProviderServer, error) {
+	return map[string]func() (tfprotov6.ProviderServer, error){
+		"wirtual": func() (tfprotov6.ProviderServer, error) {
+			serverFactory, err := provider.NewServer(context.Background())
+			if err != nil {
+				return nil, err
+			}
+			return serverFactory(), nil
+		},
+	}
+}
+
 func TestProvider(t *testing.T) {
 	t.Parallel()
 	tfProvider := provider.New()
@@ -24,10 +45,8 @@ func TestProvider(t *testing.T) {
 func TestProviderEmpty(t *testing.T) {
 	t.Parallel()
 	resource.Test(t, resource.TestCase{
-		Providers: map[string]*schema.Provider{
-			"wirtual": provider.New(),
-		},
-		IsUnitTest: true,
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
 		Steps: []resource.TestStep{{
 			Config: `
 			provider "wirtual" {}
@@ -49,3 +68,161 @@ func TestProviderEmpty(t *testing.T) {
 		}},
 	})
 }
+
+func TestProviderBuildContextFile(t *testing.T) {
+	t.Run("YAML", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "build.yaml")
+		err := os.WriteFile(path, []byte(`
+transition: stop
+workspace:
+  name: dev
+owner:
+  name: owner123
+  email: owner123@example.com
+  groups: [group1, group2]
+template:
+  name: template123
+parameters:
+  region: us-east1-a
+external_auth:
+  github: supersecret
+`), 0o600)
+		require.NoError(t, err)
+		// Environment variables take precedence over the file.
+		t.Setenv("WIRTUAL_WORKSPACE_OWNER_EMAIL", "override@example.com")
+
+		resource.Test(t, resource.TestCase{
+			ProtoV6ProviderFactories: wirtualFactory(),
+			IsUnitTest:               true,
+			Steps: []resource.TestStep{{
+				Config: fmt.Sprintf(`
+				provider "wirtual" {
+					build_context_file = %q
+				}
+				data "wirtual_workspace" "me" {}
+				data "wirtual_external_auth" "github" {
+					id = "github"
+				}
+				data "wirtual_parameter" "region" {
+					name = "region"
+				}
+				`, path),
+				Check: func(state *terraform.State) error {
+					require.Len(t, state.Modules, 1)
+					workspace := state.Modules[0].Resources["data.wirtual_workspace.me"].Primary.Attributes
+					assert.Equal(t, "stop", workspace["transition"])
+					assert.Equal(t, "0", workspace["start_count"])
+					assert.Equal(t, "dev", workspace["name"])
+					assert.Equal(t, "owner123", workspace["owner"])
+					assert.Equal(t, "override@example.com", workspace["owner_email"])
+					assert.Equal(t, "group2", workspace["owner_groups.1"])
+					assert.Equal(t, "template123", workspace["template_name"])
+					externalAuth := state.Modules[0].Resources["data.wirtual_external_auth.github"].Primary.Attributes
+					assert.Equal(t, "supersecret", externalAuth["access_token"])
+					parameter := state.Modules[0].Resources["data.wirtual_parameter.region"].Primary.Attributes
+					assert.Equal(t, "us-east1-a", parameter["value"])
+					return nil
+				},
+			}},
+		})
+	})
+
+	t.Run("JSONFromEnv", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "build.json")
+		err := os.WriteFile(path, []byte(`{"owner": {"full_name": "Mr Owner"}}`), 0o600)
+		require.NoError(t, err)
+		t.Setenv("WIRTUAL_BUILD_CONTEXT_FILE", path)
+
+		resource.Test(t, resource.TestCase{
+			ProtoV6ProviderFactories: wirtualFactory(),
+			IsUnitTest:               true,
+			Steps: []resource.TestStep{{
+				Config: `
+				provider "wirtual" {}
+				data "wirtual_workspace_owner" "me" {}
+				`,
+				Check: func(state *terraform.State) error {
+					require.Len(t, state.Modules, 1)
+					owner := state.Modules[0].Resources["data.wirtual_workspace_owner.me"].Primary.Attributes
+					assert.Equal(t, "Mr Owner", owner["full_name"])
+					assert.Equal(t, "default", owner["name"])
+					return nil
+				},
+			}},
+		})
+	})
+
+	t.Run("UnknownField", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "build.yaml")
+		err := os.WriteFile(path, []byte("ownr:\n  name: owner123\n"), 0o600)
+		require.NoError(t, err)
+
+		resource.Test(t, resource.TestCase{
+			ProtoV6ProviderFactories: wirtualFactory(),
+			IsUnitTest:               true,
+			Steps: []resource.TestStep{{
+				Config: fmt.Sprintf(`
+				provider "wirtual" {
+					build_context_file = %q
+				}
+				data "wirtual_workspace_owner" "me" {}
+				`, path),
+				ExpectError: regexp.MustCompile("Invalid build context file"),
+			}},
+		})
+	})
+}
+
+// planDiagnostics plans the creation of a resource through the provider
+// server and returns the diagnostics of the plan, which the acceptance test
+// framework doesn't expose. Attributes that aren't given are null.
+func planDiagnostics(t *testing.T, typeName string, attributes map[string]tftypes.Value) []*tfprotov6.Diagnostic {
+	t.Helper()
+	ctx := context.Background()
+
+	serverFactory, err := provider.NewServer(ctx)
+	require.NoError(t, err)
+	server := serverFactory()
+
+	schemas, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	require.NoError(t, err)
+	require.Empty(t, schemas.Diagnostics)
+
+	providerConfig := nullObject(t, schemas.Provider.ValueType(), nil)
+	configured, err := server.ConfigureProvider(ctx, &tfprotov6.ConfigureProviderRequest{Config: &providerConfig})
+	require.NoError(t, err)
+	require.Empty(t, configured.Diagnostics)
+
+	resourceSchema, ok := schemas.ResourceSchemas[typeName]
+	require.True(t, ok, "unknown resource type %q", typeName)
+	resourceType := resourceSchema.ValueType()
+	priorState, err := tfprotov6.NewDynamicValue(resourceType, tftypes.NewValue(resourceType, nil))
+	require.NoError(t, err)
+	config := nullObject(t, resourceType, attributes)
+	planned, err := server.PlanResourceChange(ctx, &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         typeName,
+		PriorState:       &priorState,
+		ProposedNewState: &config,
+		Config:           &config,
+	})
+	require.NoError(t, err)
+	return planned.Diagnostics
+}
+
+// nullObject returns an object of the given type with the given attributes,
+// and null values for the others.
+func nullObject(t *testing.T, typ tftypes.Type, attributes map[string]tftypes.Value) tfprotov6.DynamicValue {
+	t.Helper()
+	objectType, ok := typ.(tftypes.Object)
+	require.True(t, ok, "%s is not an object type", typ)
+	values := map[string]tftypes.Value{}
+	for name, attributeType := range objectType.AttributeTypes {
+		values[name] = tftypes.NewValue(attributeType, nil)
+		if value, ok := attributes[name]; ok {
+			values[name] = value
+		}
+	}
+	value, err := tfprotov6.NewDynamicValue(typ, tftypes.NewValue(typ, values))
+	require.NoError(t, err)
+	return value
+}
@@ -34,6 +34,7 @@ func scriptResource() *schema.Resource {
 			return nil
 		},
 		ReadContext:   schema.NoopContext,
+		UpdateContext: schema.NoopContext,
 		DeleteContext: schema.NoopContext,
 		Schema: map[string]*schema.Schema{
 			"agent_id": {
@@ -45,7 +46,6 @@ func scriptResource() *schema.Resource {
 			"display_name": {
 				Type:        schema.TypeString,
 				Description: "The display name of the script to display logs in the dashboard.",
-				ForceNew:    true,
 				Required:    true,
 			},
 			"log_path": {
@@ -56,7 +56,6 @@ func scriptResource() *schema.Resource {
 			},
 			"icon": {
 				Type:     schema.TypeString,
-				ForceNew: true,
 				Optional: true,
 				Description: "A URL to an icon that will display in the dashboard. View built-in " +
 					"icons [here](https://github.com/wirtualdev/wirtual/tree/main/site/static/icon). Use a " +
@@ -2,15 +2,11 @@ package provider
 
 import (
 	"context"
-	"encoding/json"
 	"reflect"
 	"strconv"
 
-	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-
-	"github.com/wirtualdev/terraform-provider-wirtual/provider/helpers"
 )
 
 func workspaceDataSource() *schema.Resource {
@@ -19,71 +15,35 @@ func workspaceDataSource() *schema.Resource {
 
 		Description: "Use this data source to get information for the active workspace build.",
 		ReadContext: func(c context.Context, rd *schema.ResourceData, i interface{}) diag.Diagnostics {
-			transition := helpers.OptionalEnvOrDefault("WIRTUAL_WORKSPACE_TRANSITION", "start") // Default to start!
-			_ = rd.Set("transition", transition)
+			config, valid := i.(config)
+			if !valid {
+				return diag.Errorf("config was unexpected type %q", reflect.TypeOf(i).String())
+			}
+			build := config.BuildContext
+
+			_ = rd.Set("transition", build.Transition)
 
 			count := 0
-			if transition == "start" {
+			if build.Transition == "start" {
 				count = 1
 			}
 			_ = rd.Set("start_count", count)
 
-			owner := helpers.OptionalEnvOrDefault("WIRTUAL_WORKSPACE_OWNER", "default")
-			_ = rd.Set("owner", owner)
-
-			ownerEmail := helpers.OptionalEnvOrDefault("WIRTUAL_WORKSPACE_OWNER_EMAIL", "default@example.com")
-			_ = rd.Set("owner_email", ownerEmail)
-
-			ownerGroupsText := helpers.OptionalEnv("WIRTUAL_WORKSPACE_OWNER_GROUPS")
-			var ownerGroups []string
-			if ownerGroupsText != "" {
-				err := json.Unmarshal([]byte(ownerGroupsText), &ownerGroups)
-				if err != nil {
-					return diag.Errorf("couldn't parse owner groups %q", ownerGroupsText)
-				}
-			}
-			_ = rd.Set("owner_groups", ownerGroups)
-
-			ownerName := helpers.OptionalEnvOrDefault("WIRTUAL_WORKSPACE_OWNER_NAME", "default")
-			_ = rd.Set("owner_name", ownerName)
-
-			ownerID := helpers.OptionalEnvOrDefault("WIRTUAL_WORKSPACE_OWNER_ID", uuid.Nil.String())
-			_ = rd.Set("owner_id", ownerID)
-
-			ownerOIDCAccessToken := helpers.OptionalEnv("WIRTUAL_WORKSPACE_OWNER_OIDC_ACCESS_TOKEN")
-			_ = rd.Set("owner_oidc_access_token", ownerOIDCAccessToken)
-
-			name := helpers.OptionalEnvOrDefault("WIRTUAL_WORKSPACE_NAME", "default")
-			rd.Set("name", name)
+			_ = rd.Set("owner", build.Owner.Name)
+			_ = rd.Set("owner_email", build.Owner.Email)
+			_ = rd.Set("owner_groups", build.Owner.Groups)
+			_ = rd.Set("owner_name", build.Owner.FullName)
+			_ = rd.Set("owner_id", build.Owner.ID)
+			_ = rd.Set("owner_oidc_access_token", build.Owner.OIDCAccessToken)
+			_ = rd.Set("owner_session_token", build.Owner.SessionToken)
 
-			sessionToken := helpers.OptionalEnv("WIRTUAL_WORKSPACE_OWNER_SESSION_TOKEN")
-			_ = rd.Set("owner_session_token", sessionToken)
+			rd.Set("name", build.Workspace.Name)
+			rd.SetId(build.Workspace.ID)
 
-			id := helpers.OptionalEnvOrDefault("WIRTUAL_WORKSPACE_ID", uuid.NewString())
-			rd.SetId(id)
+			_ = rd.Set("template_id", build.Template.ID)
+			_ = rd.Set("template_name", build.Template.Name)
+			_ = rd.Set("template_version", build.Template.Version)
 
-			templateID, err := helpers.RequireEnv("WIRTUAL_WORKSPACE_TEMPLATE_ID")
-			if err != nil {
-				return diag.Errorf("template ID is missing: %s", err.Error())
-			}
-			_ = rd.Set("template_id", templateID)
-
-			templateName, err := helpers.RequireEnv("WIRTUAL_WORKSPACE_TEMPLATE_NAME")
-			if err != nil {
-				return diag.Errorf("template name is missing: %s", err.Error())
-			}
-			_ = rd.Set("template_name", templateName)
-
-			templateVersion, err := helpers.RequireEnv("WIRTUAL_WORKSPACE_TEMPLATE_VERSION")
-			if err != nil {
-				return diag.Errorf("template version is missing: %s", err.Error())
-			}
-			_ = rd.Set("template_version", templateVersion)
-
-			config, valid := i.(config)
-			if !valid {
-				return diag.Errorf("config was unexpected type %q", reflect.TypeOf(i).String())
-			}
 			rd.Set("access_url", config.URL.String())
 
 			rawPort := config.URL.Port()
@@ -178,6 +138,7 @@ func workspaceDataSource() *schema.Resource {
 				Computed:    true,
 				Description: "Session token for authenticating with a Wirtual deployment. It is regenerated everytime a workspace is started.",
 				Deprecated:  "Use `wirtual_workspace_owner.session_token` instead.",
+				Sensitive:   true,
 			},
 			"template_id": {
 				Type:        schema.TypeString,
@@ -2,11 +2,8 @@ package provider
 
 import (
 	"context"
-	"encoding/json"
-	"os"
-	"strings"
+	"reflect"
 
-	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -15,43 +12,21 @@ func workspaceOwnerDataSource() *schema.Resource {
 	return &schema.Resource{
 		Description: "Use this data source to fetch information about the workspace owner.",
 		ReadContext: func(ctx context.Context, rd *schema.ResourceData, i interface{}) diag.Diagnostics {
-			if idStr := os.Getenv("WIRTUAL_WORKSPACE_OWNER_ID"); idStr != "" {
-				rd.SetId(idStr)
-			} else {
-				rd.SetId(uuid.NewString())
+			config, valid := i.(config)
+			if !valid {
+				return diag.Errorf("config was unexpected type %q", reflect.TypeOf(i).String())
 			}
+			owner := config.BuildContext.Owner
 
-			if username := os.Getenv("WIRTUAL_WORKSPACE_OWNER"); username != "" {
-				_ = rd.Set("name", username)
-			} else {
-				_ = rd.Set("name", "default")
-			}
-
-			if fullname := os.Getenv("WIRTUAL_WORKSPACE_OWNER_NAME"); fullname != "" {
-				_ = rd.Set("full_name", fullname)
-			} else { // compat: field can be blank, fill in default
-				_ = rd.Set("full_name", "default")
-			}
-
-			if email := os.Getenv("WIRTUAL_WORKSPACE_OWNER_EMAIL"); email != "" {
-				_ = rd.Set("email", email)
-			} else {
-				_ = rd.Set("email", "default@example.com")
-			}
-
-			_ = rd.Set("ssh_public_key", os.Getenv("WIRTUAL_WORKSPACE_OWNER_SSH_PUBLIC_KEY"))
-			_ = rd.Set("ssh_private_key", os.Getenv("WIRTUAL_WORKSPACE_OWNER_SSH_PRIVATE_KEY"))
-
-			var groups []string
-			if groupsRaw, ok := os.LookupEnv("WIRTUAL_WORKSPACE_OWNER_GROUPS"); ok {
-				if err := json.NewDecoder(strings.NewReader(groupsRaw)).Decode(&groups); err != nil {
-					return diag.Errorf("invalid user groups: %s", err.Error())
-				}
-			}
-			_ = rd.Set("groups", groups)
-
-			_ = rd.Set("session_token", os.Getenv("WIRTUAL_WORKSPACE_OWNER_SESSION_TOKEN"))
-			_ = rd.Set("oidc_access_token", os.Getenv("WIRTUAL_WORKSPACE_OWNER_OIDC_ACCESS_TOKEN"))
+			rd.SetId(owner.ID)
+			_ = rd.Set("name", owner.Name)
+			_ = rd.Set("full_name", owner.FullName)
+			_ = rd.Set("email", owner.Email)
+			_ = rd.Set("ssh_public_key", owner.SSHPublicKey)
+			_ = rd.Set("ssh_private_key", owner.SSHPrivateKey)
+			_ = rd.Set("groups", owner.Groups)
+			_ = rd.Set("session_token", owner.SessionToken)
+			_ = rd.Set("oidc_access_token", owner.OIDCAccessToken)
 
 			return nil
 		},
@@ -96,16 +71,18 @@ func workspaceOwnerDataSource() *schema.Resource {
 				Description: "The groups of which the user is a member.",
 			},
 			"session_token": {
-				Type:        schema.TypeString,
-				Computed:    true,
-				Description: "Session token for authenticating with a Wirtual deployment. It is regenerated every time a workspace is started.",
+				Type:     schema.TypeString,
+				Computed: true,
+				Description: "Session token for authenticating with a Wirtual deployment. It is regenerated every time a workspace is started. " +
+					"Use the `wirtual_owner_credentials` ephemeral resource to keep it out of the Terraform state.",
 			},
 			"oidc_access_token": {
 				Type:     schema.TypeString,
 				Computed: true,
 				Description: "A valid OpenID Connect access token of the workspace owner. " +
 					"This is only available if the workspace owner authenticated with OpenID Connect. " +
-					"If a valid token cannot be obtained, this value will be an empty string.",
+					"If a valid token cannot be obtained, this value will be an empty string. " +
+					"Use the `wirtual_owner_credentials` ephemeral resource to keep it out of the Terraform state.",
 			},
 		},
 	}
@@ -5,11 +5,9 @@ import (
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"github.com/wirtualdev/terraform-provider-wirtual/provider"
 )
 
 const (
@@ -37,10 +35,8 @@ func TestWorkspaceOwnerDatasource(t *testing.T) {
 		t.Setenv("WIRTUAL_WORKSPACE_OWNER_OIDC_ACCESS_TOKEN", `alsosupersecret`)
 
 		resource.Test(t, resource.TestCase{
-			Providers: map[string]*schema.Provider{
-				"wirtual": provider.New(),
-			},
-			IsUnitTest: true,
+			ProtoV6ProviderFactories: wirtualFactory(),
+			IsUnitTest:               true,
 			Steps: []resource.TestStep{{
 				Config: `
 			provider "wirtual" {}
@@ -86,10 +82,8 @@ func TestWorkspaceOwnerDatasource(t *testing.T) {
 		}
 
 		resource.Test(t, resource.TestCase{
-			Providers: map[string]*schema.Provider{
-				"wirtual": provider.New(),
-			},
-			IsUnitTest: true,
+			ProtoV6ProviderFactories: wirtualFactory(),
+			IsUnitTest:               true,
 			Steps: []resource.TestStep{{
 				Config: `
 			provider "wirtual" {}
@@ -1,16 +1,14 @@
 package provider_test
 
 import (
+	"os"
 	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-
-	"github.com/wirtualdev/terraform-provider-wirtual/provider"
 )
 
 func TestWorkspace(t *testing.T) {
@@ -26,10 +24,8 @@ func TestWorkspace(t *testing.T) {
 	t.Setenv("WIRTUAL_WORKSPACE_TEMPLATE_VERSION", "v1.2.3")
 
 	resource.Test(t, resource.TestCase{
-		Providers: map[string]*schema.Provider{
-			"wirtual": provider.New(),
-		},
-		IsUnitTest: true,
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
 		Steps: []resource.TestStep{{
 			Config: `
 			provider "wirtual" {
@@ -74,10 +70,8 @@ func TestWorkspace_UndefinedOwner(t *testing.T) {
 	t.Setenv("WIRTUAL_WORKSPACE_TEMPLATE_VERSION", "v1.2.3")
 
 	resource.Test(t, resource.TestCase{
-		Providers: map[string]*schema.Provider{
-			"wirtual": provider.New(),
-		},
-		IsUnitTest: true,
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
 		Steps: []resource.TestStep{{
 			Config: `
 			provider "wirtual" {
@@ -119,10 +113,8 @@ func TestWorkspace_MissingTemplateName(t *testing.T) {
 	t.Setenv("WIRTUAL_WORKSPACE_TEMPLATE_VERSION", "v1.2.3")
 
 	resource.Test(t, resource.TestCase{
-		Providers: map[string]*schema.Provider{
-			"wirtual": provider.New(),
-		},
-		IsUnitTest: true,
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
 		Steps: []resource.TestStep{{
 			Config: `
 			provider "wirtual" {
@@ -134,3 +126,76 @@ func TestWorkspace_MissingTemplateName(t *testing.T) {
 		}},
 	})
 }
+
+func TestWorkspace_InvalidBuildContext(t *testing.T) {
+	for _, tc := range []struct {
+		Name        string
+		Env         map[string]string
+		ExpectError *regexp.Regexp
+	}{{
+		Name: "MalformedOwnerGroups",
+		Env: map[string]string{
+			"WIRTUAL_WORKSPACE_OWNER_GROUPS": `["group1", "group2"`,
+		},
+		ExpectError: regexp.MustCompile("Invalid WIRTUAL_WORKSPACE_OWNER_GROUPS"),
+	}, {
+		Name: "UnknownTransition",
+		Env: map[string]string{
+			"WIRTUAL_WORKSPACE_TRANSITION": "restart",
+		},
+		ExpectError: regexp.MustCompile("Invalid WIRTUAL_WORKSPACE_TRANSITION"),
+	}} {
+		t.Run(tc.Name, func(t *testing.T) {
+			for k, v := range tc.Env {
+				t.Setenv(k, v)
+			}
+
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: wirtualFactory(),
+				IsUnitTest:               true,
+				Steps: []resource.TestStep{{
+					Config: `
+					provider "wirtual" {}
+					data "wirtual_workspace_owner" "me" {}
+					`,
+					ExpectError: tc.ExpectError,
+				}},
+			})
+		})
+	}
+}
+
+func TestWorkspace_ConsistentBuildContext(t *testing.T) {
+	for _, v := range []string{
+		"WIRTUAL_WORKSPACE_ID",
+		"WIRTUAL_WORKSPACE_OWNER_ID",
+	} { // https://github.com/golang/go/issues/52817
+		t.Setenv(v, "")
+		os.Unsetenv(v)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: wirtualFactory(),
+		IsUnitTest:               true,
+		Steps: []resource.TestStep{{
+			Config: `
+			provider "wirtual" {}
+			data "wirtual_workspace" "me" {}
+			data "wirtual_workspace_owner" "me" {}
+			`,
+			Check: func(state *terraform.State) error {
+				require.Len(t, state.Modules, 1)
+				workspace := state.Modules[0].Resources["data.wirtual_workspace.me"]
+				require.NotNil(t, workspace)
+				owner := state.Modules[0].Resources["data.wirtual_workspace_owner.me"]
+				require.NotNil(t, owner)
+
+				assert.Equal(t, owner.Primary.Attributes["id"], workspace.Primary.Attributes["owner_id"])
+				assert.Equal(t, owner.Primary.Attributes["name"], workspace.Primary.Attributes["owner"])
+				assert.Equal(t, owner.Primary.Attributes["full_name"], workspace.Primary.Attributes["owner_name"])
+				assert.Equal(t, owner.Primary.Attributes["email"], workspace.Primary.Attributes["owner_email"])
+				return nil
+			},
+		}},
+	})
+}